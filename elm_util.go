@@ -1,14 +1,101 @@
 package main
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
-func precedence(s string) string {
-	if strings.ContainsRune(s, ' ') {
-		return "(" + s + ")"
+// ElmExpr is an Elm type expression, or a decoder or encoder expression, such as
+// `List (Maybe Int)` or `D.list (D.nullable D.int)`.  Expressions are composed structurally, and
+// only parenthesized when formatted, so arguments nest correctly at any depth.
+type ElmExpr struct {
+	fn     string // Function, type constructor, or lambda head.
+	args   []*ElmExpr
+	tuple  bool     // The args are the elements of a tuple.
+	list   bool     // The args are the elements of a list.
+	lambda bool     // The single arg is the body of the lambda.
+	fields []string // The args are the values of these record fields.
+	// compound expressions given in source format must be parenthesized as arguments.
+	compound bool
+}
+
+// elmRef returns an expression referring to a type, function or value by name.
+func elmRef(name string) *ElmExpr {
+	return &ElmExpr{fn: name}
+}
+
+// elmSource returns an expression given in Elm source format, such as a decoder configured by
+// the user.
+func elmSource(s string) *ElmExpr {
+	enclosed := strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")")
+	return &ElmExpr{fn: s, compound: strings.ContainsRune(s, ' ') && !enclosed}
+}
+
+// elmApply returns the application of a function or type constructor to args.
+func elmApply(fn string, args ...*ElmExpr) *ElmExpr {
+	return &ElmExpr{fn: fn, args: args}
+}
+
+// elmTuple returns a tuple of elems.
+func elmTuple(elems ...*ElmExpr) *ElmExpr {
+	return &ElmExpr{args: elems, tuple: true}
+}
+
+// elmList returns a list of elems.
+func elmList(elems ...*ElmExpr) *ElmExpr {
+	return &ElmExpr{args: elems, list: true}
+}
+
+// elmRecord returns a record of the named fields, holding values.
+func elmRecord(fields []string, values []*ElmExpr) *ElmExpr {
+	return &ElmExpr{args: values, fields: fields}
+}
+
+// elmLambda returns a function ignoring its argument and evaluating body.
+func elmLambda(body *ElmExpr) *ElmExpr {
+	return &ElmExpr{fn: "\\_ ->", args: []*ElmExpr{body}, lambda: true}
+}
+
+// String formats the expression in Elm source format.
+func (e *ElmExpr) String() string {
+	if e.tuple || e.list {
+		elems := make([]string, len(e.args))
+		for i, el := range e.args {
+			elems[i] = el.String()
+		}
+		if e.tuple {
+			return "( " + strings.Join(elems, ", ") + " )"
+		}
+		if len(elems) == 0 {
+			return "[]"
+		}
+		return "[ " + strings.Join(elems, ", ") + " ]"
+	}
+	if e.fields != nil {
+		fields := make([]string, len(e.args))
+		for i, value := range e.args {
+			fields[i] = e.fields[i] + " = " + value.String()
+		}
+		return "{ " + strings.Join(fields, ", ") + " }"
+	}
+	if e.lambda {
+		return e.fn + " " + e.args[0].String()
+	}
+	s := e.fn
+	for _, arg := range e.args {
+		s += " " + arg.Arg()
 	}
 	return s
 }
 
+// Arg formats the expression as a function argument, parenthesized unless it is atomic.
+func (e *ElmExpr) Arg() string {
+	if e.tuple || e.list || e.fields != nil || (len(e.args) == 0 && !e.compound) {
+		return e.String()
+	}
+	return "(" + e.String() + ")"
+}
+
 func splitTypeNamePair(s string) (string, string) {
 	els := strings.Split(s, ":")
 	goName := els[0]
@@ -32,8 +119,41 @@ func (m TypeNamePairs) Add(s string) {
 func (m TypeNamePairs) ElmName(typeName string) string {
 	recordName := m[typeName]
 	if recordName == "" {
-		camelCaseName := camelCase(typeName)
-		recordName = strings.ToUpper(camelCaseName[:1]) + camelCaseName[1:]
+		recordName = pascalCase(typeName)
 	}
 	return recordName
 }
+
+// pascalCase is camelCase leading with uppercase, the convention for Elm type names.
+func pascalCase(s string) string {
+	camelCaseName := camelCase(s)
+	return strings.ToUpper(camelCaseName[:1]) + camelCaseName[1:]
+}
+
+// elmQuote returns s as an Elm string literal.
+func elmQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < ' ' || r == 0x7f {
+				fmt.Fprintf(&b, "\\u{%04X}", r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -2,21 +2,60 @@ package main
 
 import "testing"
 
-func TestPrecedence(t *testing.T) {
+func TestElmExpr(t *testing.T) {
+	str := elmRef("String")
+	testCases := []struct {
+		expr      *ElmExpr
+		want, arg string
+	}{
+		{str, "String", "String"},
+		{elmApply("List", str), "List String", "(List String)"},
+		{elmApply("List", elmApply("List", str)), "List (List String)", "(List (List String))"},
+		{elmApply("List", elmApply("Maybe", str)), "List (Maybe String)", "(List (Maybe String))"},
+		{elmApply("Dict", str, elmApply("Maybe", str)), "Dict String (Maybe String)",
+			"(Dict String (Maybe String))"},
+		{elmTuple(str, elmApply("List", str)), "( String, List String )", "( String, List String )"},
+		{elmApply("List", elmTuple(str, str)), "List ( String, String )", "(List ( String, String ))"},
+		{elmApply("D.oneOf", elmList(elmApply("D.null", elmList()), elmApply("D.list", str))),
+			"D.oneOf [ D.null [], D.list String ]", "(D.oneOf [ D.null [], D.list String ])"},
+		{elmRecord([]string{"name", "tags"}, []*ElmExpr{elmRef(`""`), elmList()}),
+			`{ name = "", tags = [] }`, `{ name = "", tags = [] }`},
+		{elmApply("D.list", elmSource("D.map Money.fromCents D.int")),
+			"D.list (D.map Money.fromCents D.int)", "(D.list (D.map Money.fromCents D.int))"},
+		{elmSource("(D.map Money.fromCents D.int)"), "(D.map Money.fromCents D.int)",
+			"(D.map Money.fromCents D.int)"},
+		{elmApply("D.lazy", elmLambda(elmApply("pageDecoder", elmRef("D.int")))),
+			"D.lazy (\\_ -> pageDecoder D.int)", "(D.lazy (\\_ -> pageDecoder D.int))"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.want, func(t *testing.T) {
+			if got := tc.expr.String(); got != tc.want {
+				t.Errorf("String() got %q, want %q", got, tc.want)
+			}
+			if got := tc.expr.Arg(); got != tc.arg {
+				t.Errorf("Arg() got %q, want %q", got, tc.arg)
+			}
+		})
+	}
+}
+
+func TestElmQuote(t *testing.T) {
 	testCases := []struct {
 		input, want string
 	}{
-		{"", ""},
-		{"String", "String"},
-		{"List String", "(List String)"},
+		{"", `""`},
+		{"active", `"active"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{`back\slash`, `"back\\slash"`},
+		{"line\nbreak", `"line\nbreak"`},
+		{"bell\a", `"bell\u{0007}"`},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.input, func(t *testing.T) {
-			got := precedence(tc.input)
+			got := elmQuote(tc.input)
 			if got != tc.want {
-				t.Errorf("%q got %q, want %q", tc.input, got, tc.want)
+				t.Errorf("elmQuote(%q) got %s, want %s", tc.input, got, tc.want)
 			}
 		})
 	}
-
 }
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"go/constant"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// ElmEnum represents an Elm custom type, generated from a Go type and its typed constants.
+type ElmEnum struct {
+	name   string
+	basic  *ElmBasicType // JSON representation, elmString or elmInt.
+	Values []*ElmEnumValue
+	Label  bool // Go type has a String method.
+}
+
+// ElmEnumValue is a constructor of an Elm custom type, and the JSON value it represents.
+type ElmEnumValue struct {
+	Constructor string
+	GoName      string
+	// Literal is the JSON value in Elm source format.
+	Literal string
+}
+
+// Name of this custom type.
+func (e *ElmEnum) Name() string {
+	return e.name
+}
+
+// CamelCasedName leads with lowercase.
+func (e *ElmEnum) CamelCasedName() string {
+	return camelCase(e.name)
+}
+
+// Decoder for this custom type.
+func (e *ElmEnum) Decoder(prefix string) string {
+	return e.CamelCasedName() + "Decoder"
+}
+
+// Encoder for this custom type.
+func (e *ElmEnum) Encoder(prefix string) string {
+	return "encode" + e.name
+}
+
+// TypeExpr returns the Elm type expression.
+func (e *ElmEnum) TypeExpr() *ElmExpr {
+	return elmRef(e.name)
+}
+
+// DecoderExpr returns the Elm JSON decoder expression for this type.
+func (e *ElmEnum) DecoderExpr(prefix string) *ElmExpr {
+	return elmRef(e.Decoder(prefix))
+}
+
+// EncoderExpr returns the Elm JSON encoder expression for this type.
+func (e *ElmEnum) EncoderExpr(prefix string) *ElmExpr {
+	return elmRef(e.Encoder(prefix))
+}
+
+// Basic returns the Elm type of the JSON values.
+func (e *ElmEnum) Basic() *ElmBasicType {
+	return e.basic
+}
+
+// IsString indicates the JSON values are strings rather than integers.
+func (e *ElmEnum) IsString() bool {
+	return e.basic == elmString
+}
+
+// ToJSON returns the name of the function converting this type to its JSON value.
+func (e *ElmEnum) ToJSON() string {
+	return e.CamelCasedName() + "To" + e.basic.Name()
+}
+
+// FromJSON returns the name of the function converting a JSON value to this type.
+func (e *ElmEnum) FromJSON() string {
+	return e.CamelCasedName() + "From" + e.basic.Name()
+}
+
+// LabelFunc returns the name of the function converting this type to its Go constant name.
+func (e *ElmEnum) LabelFunc() string {
+	return e.CamelCasedName() + "Label"
+}
+
+// All returns the name of the list containing every constructor of this type.
+func (e *ElmEnum) All() string {
+	return "all" + e.name
+}
+
+// Equal tests for equality with another ElmType.
+func (e *ElmEnum) Equal(other ElmType) bool {
+	if o, ok := other.(*ElmEnum); ok {
+		if e.name != o.name || e.basic != o.basic || e.Label != o.Label ||
+			len(e.Values) != len(o.Values) {
+			return false
+		}
+		for i, v := range e.Values {
+			if *v != *o.Values[i] {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Nullable indicates whether this type can be nil.
+func (e *ElmEnum) Nullable() bool {
+	return false
+}
+
+// KeyParser returns the Elm function converting a JSON object key into this type.
+func (e *ElmEnum) KeyParser() string {
+	if e.IsString() {
+		return e.FromJSON()
+	}
+	return "(" + e.basic.KeyParser() + " >> Maybe.andThen " + e.FromJSON() + ")"
+}
+
+// KeyFormatter returns the Elm function converting this type into a JSON object key.
+func (e *ElmEnum) KeyFormatter() string {
+	if e.IsString() {
+		return e.ToJSON()
+	}
+	return "(" + e.ToJSON() + " >> " + e.basic.KeyFormatter() + ")"
+}
+
+// Comparable indicates whether this type may be used as an Elm Dict key.
+func (e *ElmEnum) Comparable() bool {
+	return false
+}
+
+// enumConstants returns the constants of the named type declared in its package, in source order.
+func enumConstants(t *types.Named) []*types.Const {
+	pkg := t.Obj().Pkg()
+	if pkg == nil {
+		return nil
+	}
+	var consts []*types.Const
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		if c, ok := scope.Lookup(name).(*types.Const); ok && types.Identical(c.Type(), t) {
+			consts = append(consts, c)
+		}
+	}
+	sort.Slice(consts, func(i, j int) bool { return consts[i].Pos() < consts[j].Pos() })
+	return consts
+}
+
+// isEnum tests whether the constants of a named type make it an enum.  A single value, such as a
+// named zero value, does not.
+func isEnum(consts []*types.Const) bool {
+	for _, c := range consts {
+		if !constant.Compare(c.Val(), token.EQL, consts[0].Val()) {
+			return true
+		}
+	}
+	return false
+}
+
+// enumFromConstants builds an Elm custom type named name from the constants of a named string or
+// integer type.
+func enumFromConstants(name string, t *types.Named, consts []*types.Const) (*ElmEnum, error) {
+	goName := t.Obj().Name()
+	enum := &ElmEnum{
+		name:  name,
+		basic: elmString,
+		Label: hasStringMethod(t),
+	}
+	kind := constant.String
+	if !isString(t) {
+		enum.basic = elmInt
+		kind = constant.Int
+	}
+	seen := make(map[string]bool)
+	for _, c := range consts {
+		if c.Val().Kind() != kind {
+			return nil, errors.Errorf("constant %s has kind %v, want %v", c.Name(), c.Val().Kind(), kind)
+		}
+		literal := c.Val().ExactString()
+		if kind == constant.String {
+			literal = elmQuote(constant.StringVal(c.Val()))
+		}
+		if seen[literal] {
+			// Aliases of an earlier constant share its constructor.
+			continue
+		}
+		seen[literal] = true
+		enum.Values = append(enum.Values, &ElmEnumValue{
+			Constructor: constructorName(goName, c.Name()),
+			GoName:      c.Name(),
+			Literal:     literal,
+		})
+	}
+	return enum, nil
+}
+
+// hasStringMethod tests if t implements fmt.Stringer.
+func hasStringMethod(t types.Type) bool {
+	obj, _, _ := types.LookupFieldOrMethod(t, true, nil, "String")
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return false
+	}
+	sig := fn.Type().(*types.Signature)
+	return sig.Params().Len() == 0 && sig.Results().Len() == 1 &&
+		types.Identical(sig.Results().At(0).Type(), types.Typ[types.String])
+}
+
+// constructorName strips the type name prefix from a constant name, and converts the remainder
+// into an Elm constructor name.  The full constant name is used if nothing usable remains.
+func constructorName(typeName, constName string) string {
+	name := constName
+	for _, prefix := range []string{typeName, camelCase(typeName)} {
+		if strings.HasPrefix(name, prefix) {
+			name = strings.TrimLeft(name[len(prefix):], "_")
+			break
+		}
+	}
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = constName
+	}
+	return pascalCase(name)
+}
@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestConstructorName(t *testing.T) {
+	testCases := []struct {
+		typeName, constName, want string
+	}{
+		{"Status", "StatusActive", "Active"},
+		{"Status", "Active", "Active"},
+		{"Status", "statusHidden", "Hidden"},
+		{"Status", "Status_Archived", "Archived"},
+		{"Status", "Status", "Status"},
+		{"Status", "Status2", "Status2"},
+		{"Method", "MethodHTTPGet", "HttpGet"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.constName, func(t *testing.T) {
+			got := constructorName(tc.typeName, tc.constName)
+			if got != tc.want {
+				t.Errorf("constructorName(%q, %q) got %q, want %q",
+					tc.typeName, tc.constName, got, tc.want)
+			}
+		})
+	}
+}
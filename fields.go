@@ -0,0 +1,196 @@
+package main
+
+import (
+	"go/types"
+	"sort"
+)
+
+// jsonField is a struct field visible to encoding/json, possibly promoted from an embedded struct.
+type jsonField struct {
+	name       string // JSON object key.
+	goName     string
+	goPath     string // Selector through embedded structs, such as `BaseModel.ID`.
+	goType     types.Type
+	options    string // Comma-separated tag options.
+	elm        string // Value of the elm tag.
+	tagged     bool   // Name came from a json tag.
+	index      []int  // Field index sequence through embedded structs.
+	viaPointer bool   // Promoted through an embedded pointer, absent when it is nil.
+}
+
+// embedded is an anonymous struct field waiting to have its fields explored.
+type embedded struct {
+	structDef  *types.Struct
+	index      []int
+	path       string // Selector prefix of the promoted fields, such as `BaseModel.`.
+	viaPointer bool
+}
+
+// typeFields returns the fields encoding/json marshals for structDef.  It follows the algorithm
+// of encoding/json's typeFields: the fields of embedded structs are promoted breadth first, and
+// name conflicts are resolved in favor of the shallowest field, then the tagged field.  Conflicts
+// that cannot be resolved drop every field sharing the name.
+func typeFields(structDef *types.Struct) []jsonField {
+	var fields []jsonField
+	current := []embedded{}
+	next := []embedded{{structDef: structDef}}
+
+	// Count of queued names for current level and the next.
+	var count, nextCount map[*types.Struct]int
+
+	// Types already visited at an earlier level.
+	visited := map[*types.Struct]bool{}
+
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		count, nextCount = nextCount, map[*types.Struct]int{}
+
+		for _, f := range current {
+			if visited[f.structDef] {
+				continue
+			}
+			visited[f.structDef] = true
+
+			for i := 0; i < f.structDef.NumFields(); i++ {
+				sf := f.structDef.Field(i)
+				if sf.Embedded() {
+					t := sf.Type()
+					if p, ok := t.Underlying().(*types.Pointer); ok {
+						t = p.Elem()
+					}
+					if _, ok := t.Underlying().(*types.Struct); !sf.Exported() && !ok {
+						// Ignore embedded fields of unexported non-struct types.
+						continue
+					}
+					// Do not ignore embedded fields of unexported struct types, since they may
+					// have exported fields.
+				} else if !sf.Exported() {
+					continue
+				}
+				stag := f.structDef.Tag(i)
+				if jsonTag(stag) == "-" {
+					continue
+				}
+				name, opts := parseTag(stag)
+				if !isValidTag(name) {
+					name = ""
+				}
+				index := make([]int, len(f.index)+1)
+				copy(index, f.index)
+				index[len(f.index)] = i
+
+				ft := sf.Type()
+				viaPointer := f.viaPointer
+				if p, ok := ft.(*types.Pointer); ok {
+					ft = p.Elem()
+					viaPointer = viaPointer || sf.Embedded()
+				}
+				st, isStruct := ft.Underlying().(*types.Struct)
+
+				// Record found field and index sequence.
+				if name != "" || !sf.Embedded() || !isStruct {
+					tagged := name != ""
+					if name == "" {
+						name = sf.Name()
+					}
+					fields = append(fields, jsonField{
+						name:       name,
+						goName:     sf.Name(),
+						goPath:     f.path + sf.Name(),
+						goType:     sf.Type(),
+						options:    opts,
+						elm:        elmTag(stag),
+						tagged:     tagged,
+						index:      index,
+						viaPointer: f.viaPointer,
+					})
+					if count[f.structDef] > 1 {
+						// If there were multiple instances, add a second, so that the annihilation
+						// code will see a duplicate.
+						fields = append(fields, fields[len(fields)-1])
+					}
+					continue
+				}
+
+				// Record new anonymous struct to explore in next round.
+				nextCount[st]++
+				if nextCount[st] == 1 {
+					next = append(next, embedded{structDef: st, index: index,
+						path: f.path + sf.Name() + ".", viaPointer: viaPointer})
+				}
+			}
+		}
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		x := fields
+		// Sort field by name, breaking ties with depth, then breaking ties with "name came from
+		// json tag", then breaking ties with index sequence.
+		if x[i].name != x[j].name {
+			return x[i].name < x[j].name
+		}
+		if len(x[i].index) != len(x[j].index) {
+			return len(x[i].index) < len(x[j].index)
+		}
+		if x[i].tagged != x[j].tagged {
+			return x[i].tagged
+		}
+		return indexLess(x[i].index, x[j].index)
+	})
+
+	// Delete all fields that are hidden by the Go rules for embedded fields, except that fields
+	// with JSON tags are promoted.
+	out := fields[:0]
+	for advance, i := 0, 0; i < len(fields); i += advance {
+		// One iteration per name.  Find the sequence of fields with the name of this first field.
+		fi := fields[i]
+		for advance = 1; i+advance < len(fields); advance++ {
+			if fields[i+advance].name != fi.name {
+				break
+			}
+		}
+		if advance == 1 {
+			// Only one field with this name.
+			out = append(out, fi)
+			continue
+		}
+		if dominant, ok := dominantField(fields[i : i+advance]); ok {
+			out = append(out, dominant)
+		}
+	}
+
+	fields = out
+	sort.Slice(fields, func(i, j int) bool {
+		return indexLess(fields[i].index, fields[j].index)
+	})
+
+	return fields
+}
+
+// dominantField looks through the fields, all of which are known to have the same name, to find
+// the single field that dominates the others using Go's embedding rules, modified by the presence
+// of JSON tags.  If there are multiple top-level fields, false is returned.  This condition is an
+// error in Go and we skip all the fields.
+func dominantField(fields []jsonField) (jsonField, bool) {
+	// The fields are sorted in increasing index-length order, then by presence of tag.  That means
+	// that the first field is the dominant one.  We need only check for error cases: two fields
+	// at top level, either both tagged or neither tagged.
+	if len(fields) > 1 && len(fields[0].index) == len(fields[1].index) &&
+		fields[0].tagged == fields[1].tagged {
+		return jsonField{}, false
+	}
+	return fields[0], true
+}
+
+// indexLess orders field index sequences.
+func indexLess(a, b []int) bool {
+	for k, xik := range a {
+		if k >= len(b) {
+			return false
+		}
+		if xik != b[k] {
+			return xik < b[k]
+		}
+	}
+	return len(a) < len(b)
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"runtime"
+	"sort"
 	"text/template"
 
 	"github.com/pkg/errors"
@@ -24,10 +25,23 @@ var (
 	logger = zerolog.New(logWriter)
 )
 
+// baseImports are required by every generated Elm module.
+var baseImports = []string{
+	"Json.Decode as D",
+	"Json.Decode.Pipeline as P",
+	"Json.Encode as E",
+}
+
 // TemplateData holds the context for the template.
 type TemplateData struct {
-	Record *ElmRecord
-	Nested []*ElmRecord
+	Imports  []string
+	Notes    []string
+	Record   *ElmRecord
+	Nested   []*ElmRecord
+	Enums    []*ElmEnum
+	Unions   []*ElmUnion
+	Wrappers []*ElmWrapper
+	Helpers  []string
 }
 
 const help = `
@@ -49,6 +63,28 @@ Usage Example:
 func main() {
 	// Flags.
 	verbose := flag.Bool("v", false, "verbose (debug) output")
+	opaque := flag.Bool("opaque", false, "wrap named basic types in opaque custom types, not aliases")
+	wrappers := make(TypeNames)
+	flag.Var(wrappers, "wrapper", "represent a named basic type as a wrapper, not an enum of its\n"+
+		"constants, may be repeated")
+	unions := make(Unions)
+	flag.Var(unions, "union", "generate a tagged union for an interface, may be repeated:\n"+
+		"Iface:discriminator[:Struct=tag,...]")
+	mappings := make(Mappings)
+	flag.Var(mappings, "map", "represent a Go type by an Elm type, may be repeated:\n"+
+		"GoType=ElmType:import:decoder:encoder")
+	bytes := flag.Bool("bytes", false, "decode base64 byte slices into Bytes, not Strings")
+	tuples := flag.Bool("tuples", false, "represent arrays of 2 or 3 elements as tuples, not Lists")
+	var int64Policy Int64Policy
+	flag.Var(&int64Policy, "int64", "representation of 64-bit integers: int, string (for fields\n"+
+		"with the ,string option), or warn")
+	omitZeros := flag.Bool("omit-zeros", false, "leave omitempty fields holding zero values out of\n"+
+		"encoded objects")
+	zeroDefaults := flag.Bool("zero-defaults", false, "decode absent omitempty fields as Go zero\n"+
+		"values, not Maybe")
+	var nilPolicy NilPolicy
+	flag.Var(&nilPolicy, "nil", "representation of nil slices and maps: empty (decode null as\n"+
+		"empty), null (also encode empty as null), or maybe")
 	color := flag.Bool("color", runtime.GOOS != "windows", "colorize debug output")
 	flag.Usage = func() {
 		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [opts] <go files> -- <pkg name> \\\n"+
@@ -97,7 +133,19 @@ func main() {
 	}
 
 	// Output Elm.
-	err = generateElm(os.Stdout, pkgs, packageName, objectName, renames)
+	options := Options{
+		Opaque:       *opaque,
+		Wrappers:     wrappers,
+		Unions:       unions,
+		Mappings:     mappings,
+		Int64:        int64Policy,
+		Bytes:        *bytes,
+		Tuples:       *tuples,
+		Nils:         nilPolicy,
+		OmitZeros:    *omitZeros,
+		ZeroDefaults: *zeroDefaults,
+	}
+	err = generateElm(os.Stdout, pkgs, packageName, objectName, renames, options)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("Generation failed")
 	}
@@ -109,7 +157,8 @@ func generateElm(
 	pkgs []*packages.Package,
 	packageName string,
 	objectName string,
-	renames TypeNamePairs) error {
+	renames TypeNamePairs,
+	options Options) error {
 	// Load output template.
 	tmpl, err := template.New("elm").Parse(elmTemplate)
 	if err != nil {
@@ -117,20 +166,32 @@ func generateElm(
 	}
 
 	// Process definition.
-	structType, err := getStructDef(pkgs, packageName, objectName)
+	namedType, err := getNamedStruct(pkgs, packageName, objectName)
 	if err != nil {
 		return errors.Wrap(err, "Couldn't find struct")
 	}
-	resolver := NewResolver(renames)
-	record, err := recordFromStruct(resolver, structType, objectName)
+	resolver := NewResolver(renames, options)
+	record, err := resolver.ResolveRoot(namedType)
 	if err != nil {
 		return errors.Wrap(err, "Couldn't convert struct")
 	}
 
 	// Render Elm.
+	imports := append(resolver.Imports(), baseImports...)
+	sort.Strings(imports)
+	var helpers []string
+	for _, name := range resolver.Helpers() {
+		helpers = append(helpers, elmHelpers[name])
+	}
 	data := &TemplateData{
-		Record: record,
-		Nested: resolver.CachedRecords(),
+		Imports:  imports,
+		Notes:    resolver.Notes(),
+		Record:   record,
+		Nested:   resolver.CachedRecords(),
+		Enums:    resolver.CachedEnums(),
+		Unions:   resolver.CachedUnions(),
+		Wrappers: resolver.CachedWrappers(),
+		Helpers:  helpers,
 	}
 	err = tmpl.Execute(w, data)
 	if err != nil {
@@ -173,6 +234,15 @@ func loadPackages(args []string) (pkgs []*packages.Package, err error) {
 
 // getStructDef finds the requested object and confirms it's a struct type definition.
 func getStructDef(pkgs []*packages.Package, packageName, typeName string) (*types.Struct, error) {
+	namedType, err := getNamedStruct(pkgs, packageName, typeName)
+	if err != nil {
+		return nil, err
+	}
+	return namedType.Underlying().(*types.Struct), nil
+}
+
+// getNamedStruct finds the requested object and confirms it's a named struct type.
+func getNamedStruct(pkgs []*packages.Package, packageName, typeName string) (*types.Named, error) {
 	// Lookup package.
 	var pkg *packages.Package
 	for _, p := range pkgs {
@@ -190,11 +260,14 @@ func getStructDef(pkgs []*packages.Package, packageName, typeName string) (*type
 	if obj == nil {
 		return nil, errors.Errorf("Definition %s.%s not found", packageName, typeName)
 	}
-	objType := obj.Type().Underlying()
-	structType, ok := objType.(*types.Struct)
+	namedType, ok := obj.Type().(*types.Named)
 	if !ok {
+		return nil, errors.Errorf("%s type is %T, want *types.Named", obj.Id(), obj.Type())
+	}
+	objType := namedType.Underlying()
+	if _, ok := objType.(*types.Struct); !ok {
 		return nil, errors.Errorf("%s type is %T, want *types.Struct", obj.Id(), objType)
 	}
 
-	return structType, nil
+	return namedType, nil
 }
@@ -22,12 +22,83 @@ func TestMainOutput(t *testing.T) {
 		{"NestedStructs", "nestedstructs.golden"},
 		{"OptionalValues", "optionalvalues.golden"},
 		{"NullableValues", "nullablevalues.golden"},
+		{"MapTypes", "maptypes.golden"},
+		{"KeyedMaps", "keyedmaps.golden"},
+		{"SkippedFields", "skippedfields.golden"},
+		{"EmbeddedStructs", "embeddedstructs.golden"},
+		{"StringEnums", "stringenums.golden"},
+		{"IntEnums", "intenums.golden"},
+		{"NamedBasics", "namedbasics.golden"},
+		{"TimeTypes", "timetypes.golden"},
+		{"Thread", "thread.golden"},
+		{"Category", "category.golden"},
+		{"Addresses", "addresses.golden"},
+		{"GenericTypes", "generictypes.golden"},
+		{"GenericAnonymous", "genericanonymous.golden"},
+		{"Tree", "tree.golden"},
+		{"ValueTypes", "valuetypes.golden"},
+		{"Stringified", "stringified.golden"},
+		{"ByteSlices", "byteslices.golden"},
+		{"Keywords", "keywords.golden"},
+		{"Overrides", "overrides.golden"},
+		{"ArrayTypes", "arraytypes.golden"},
+		{"NamedArrays", "namedarrays.golden"},
+		{"NestedTypes", "nestedtypes.golden"},
+		{"Order", "order.golden"},
+		{"Response", "response.golden"},
 	}
 
 	buf := &bytes.Buffer{}
 	for _, tt := range tests {
 		buf.Reset()
-		err = generateElm(buf, pkgs, "main", tt.name, make(TypeNamePairs))
+		err = generateElm(buf, pkgs, "main", tt.name, make(TypeNamePairs), Options{})
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		goldiff.File(t, buf.Bytes(), "testdata", "examples", tt.goldenFile)
+	}
+}
+
+func TestMainOutputOptions(t *testing.T) {
+	pkgs, err := pkgCache.load(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		name, goldenFile string
+		options          Options
+	}{
+		{"NamedBasics", "namedbasicsopaque.golden", Options{Opaque: true}},
+		{"Prices", "prices.golden", Options{}},
+		{"Prices", "priceswrappers.golden", Options{Wrappers: TypeNames{"Currency": true}}},
+		{"BigInts", "bigints.golden", Options{Int64: Int64String}},
+		{"ByteSlices", "byteslicesbytes.golden", Options{Bytes: true}},
+		{"ArrayTypes", "arraytypestuples.golden", Options{Tuples: true}},
+		{"NamedArrays", "namedarraystuples.golden", Options{Tuples: true}},
+		{"HelperNames", "helpernamestuples.golden", Options{Tuples: true}},
+		{"NestedTypes", "nestedtypesnull.golden", Options{Nils: NilNull}},
+		{"NestedTypes", "nestedtypesmaybe.golden", Options{Nils: NilMaybe}},
+		{"OptionalValues", "optionalvalueszeros.golden", Options{OmitZeros: true}},
+		{"ZeroValues", "zerovalues.golden", Options{}},
+		{"ZeroValues", "zerovaluesdefaults.golden", Options{ZeroDefaults: true}},
+		{"Order", "orderzerodefaults.golden", Options{ZeroDefaults: true}},
+		{"Invoice", "invoicemappings.golden", Options{Mappings: Mappings{
+			"github.com/jhillyerd/go-to-elm-json/testdata/billing.Amount": {
+				Type: "Money.Amount", Import: "Money", Decoder: "Money.decoder", Encoder: "Money.encode",
+			},
+			"time.Time": {Type: "String", Decoder: "D.string", Encoder: "E.string"},
+		}}},
+		{"EventLog", "eventlog.golden", Options{Unions: Unions{
+			"Event": {Discriminator: "type", Tags: map[string]string{"Viewed": "view"}},
+		}}},
+	}
+
+	buf := &bytes.Buffer{}
+	for _, tt := range tests {
+		buf.Reset()
+		err = generateElm(buf, pkgs, "main", tt.name, make(TypeNamePairs), tt.options)
 		if err != nil {
 			t.Error(err)
 			continue
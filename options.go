@@ -0,0 +1,232 @@
+package main
+
+import (
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Options configures the conversion of Go types to Elm.  The zero value selects the default
+// behavior.
+type Options struct {
+	// Opaque represents named basic types, such as `type UserID string`, as a single-constructor
+	// custom type instead of a type alias.
+	Opaque bool
+	// Wrappers represents named string and integer types as wrappers of their basic type, even
+	// when their constants would make them enums.
+	Wrappers TypeNames
+	// Unions configures Go interfaces to be represented as tagged unions of their implementing
+	// structs.
+	Unions Unions
+	// Mappings represents Go types as Elm types with custom decoders and encoders, taking
+	// precedence over the built-in conversions.
+	Mappings Mappings
+	// Int64 selects the representation of 64-bit integers.
+	Int64 Int64Policy
+	// Bytes decodes the base64 strings of byte slices into Elm Bytes, instead of leaving them as
+	// Strings.
+	Bytes bool
+	// Tuples represents arrays of two or three elements as Elm tuples, instead of Lists.
+	Tuples bool
+	// Nils selects the representation of nil slices and maps.
+	Nils NilPolicy
+	// OmitZeros leaves omitempty fields holding zero values out of encoded objects, like
+	// encoding/json.  Fields holding Nothing are always left out.
+	OmitZeros bool
+	// ZeroDefaults represents non-pointer omitempty fields by their plain Elm type rather than a
+	// Maybe, decoding absent fields as the Go zero value.  Encoders leave out zero values.
+	ZeroDefaults bool
+}
+
+// NilPolicy selects the representation of Go nil slices and maps, which encoding/json encodes as
+// null.  It implements flag.Value.
+type NilPolicy int
+
+const (
+	// NilEmpty decodes null slices and maps as empty Lists and Dicts, and encodes empty ones as
+	// `[]` and `{}`.
+	NilEmpty NilPolicy = iota
+	// NilNull decodes null slices and maps as empty Lists and Dicts, and encodes empty ones as
+	// null, as Go encodes nil slices and maps.
+	NilNull
+	// NilMaybe represents slices and maps as Maybe, distinguishing null from empty.
+	NilMaybe
+)
+
+var nilPolicyNames = []string{"empty", "null", "maybe"}
+
+// Set parses the name of a policy.
+func (p *NilPolicy) Set(s string) error {
+	for i, name := range nilPolicyNames {
+		if s == name {
+			*p = NilPolicy(i)
+			return nil
+		}
+	}
+	return errors.Errorf("nil policy %q, want one of %s", s, strings.Join(nilPolicyNames, ", "))
+}
+
+// String returns the name of the policy.
+func (p *NilPolicy) String() string {
+	if p == nil || int(*p) >= len(nilPolicyNames) {
+		return nilPolicyNames[0]
+	}
+	return nilPolicyNames[*p]
+}
+
+// Int64Policy selects the representation of 64-bit integers, which may exceed the 53 bits of
+// precision available to Elm Ints.  It implements flag.Value.
+type Int64Policy int
+
+const (
+	// Int64Int represents 64-bit integers as Elm Ints.
+	Int64Int Int64Policy = iota
+	// Int64String represents 64-bit integers with the `,string` option as Elm Strings, preserving
+	// their precision.  Other 64-bit integers are reported as with Int64Warn.
+	Int64String
+	// Int64Warn represents 64-bit integers as Elm Ints, and logs a warning for each field.
+	Int64Warn
+)
+
+var int64PolicyNames = []string{"int", "string", "warn"}
+
+// Set parses the name of a policy.
+func (p *Int64Policy) Set(s string) error {
+	for i, name := range int64PolicyNames {
+		if s == name {
+			*p = Int64Policy(i)
+			return nil
+		}
+	}
+	return errors.Errorf("int64 policy %q, want one of %s", s, strings.Join(int64PolicyNames, ", "))
+}
+
+// String returns the name of the policy.
+func (p *Int64Policy) String() string {
+	if p == nil || int(*p) >= len(int64PolicyNames) {
+		return int64PolicyNames[0]
+	}
+	return int64PolicyNames[*p]
+}
+
+// TypeNames is a set of Go type names, optionally qualified by package name.  It implements
+// flag.Value.
+type TypeNames map[string]bool
+
+// Set adds a type name to the set.
+func (s TypeNames) Set(name string) error {
+	if name == "" {
+		return errors.New("empty type name")
+	}
+	s[name] = true
+	return nil
+}
+
+// String formats the type names.
+func (s TypeNames) String() string {
+	var names []string
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, " ")
+}
+
+// has tests whether the set holds the named type, by its qualified or plain name.
+func (s TypeNames) has(t *types.Named) bool {
+	return s[qualifiedTypeString(t)] || s[t.Obj().Name()]
+}
+
+// Mapping represents a Go type as an Elm type with its own decoder and encoder, such as a type
+// provided by an Elm package.
+type Mapping struct {
+	// Type is the Elm type, such as `Uuid.Uuid`.
+	Type string
+	// Import is the Elm module import required by the type, such as `Uuid`, or empty.
+	Import string
+	// Decoder is an Elm expression decoding the type, such as `Uuid.decoder`.
+	Decoder string
+	// Encoder is an Elm expression encoding the type, such as `Uuid.encode`.
+	Encoder string
+}
+
+// Mappings maps Go types, qualified by package path or name, such as
+// `github.com/google/uuid.UUID` or `uuid.UUID`, to Elm types.  It implements flag.Value.
+type Mappings map[string]Mapping
+
+// Set parses a mapping of the form `GoType=ElmType:import:decoder:encoder`, where the import may
+// be empty.
+func (m Mappings) Set(s string) error {
+	goType, def, _ := strings.Cut(s, "=")
+	parts := strings.SplitN(def, ":", 4)
+	if goType == "" || len(parts) != 4 || parts[0] == "" || parts[2] == "" || parts[3] == "" {
+		return errors.Errorf("mapping %q, want GoType=ElmType:import:decoder:encoder", s)
+	}
+	m[goType] = Mapping{Type: parts[0], Import: parts[1], Decoder: parts[2], Encoder: parts[3]}
+	return nil
+}
+
+// String formats the mappings.
+func (m Mappings) String() string {
+	var defs []string
+	for goType, mapping := range m {
+		defs = append(defs, goType+"="+strings.Join(
+			[]string{mapping.Type, mapping.Import, mapping.Decoder, mapping.Encoder}, ":"))
+	}
+	sort.Strings(defs)
+	return strings.Join(defs, " ")
+}
+
+// Union configures the tagged union representation of a Go interface.
+type Union struct {
+	// Discriminator is the JSON name of the field holding the variant tag.
+	Discriminator string
+	// Tags maps implementing struct names to their discriminator values.  Structs not listed use
+	// their Go name.
+	Tags map[string]string
+}
+
+// Unions maps Go interface names, optionally qualified by package name, to their union
+// configuration.  It implements flag.Value.
+type Unions map[string]Union
+
+// Set parses a union definition of the form `Iface:discriminator[:Struct=tag,...]`.
+func (m Unions) Set(s string) error {
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return errors.Errorf("union %q, want Iface:discriminator[:Struct=tag,...]", s)
+	}
+	u := Union{Discriminator: parts[1], Tags: make(map[string]string)}
+	if len(parts) == 3 {
+		for _, pair := range strings.Split(parts[2], ",") {
+			els := strings.SplitN(pair, "=", 2)
+			if len(els) != 2 || els[0] == "" {
+				return errors.Errorf("union %q tag %q, want Struct=tag", s, pair)
+			}
+			u.Tags[els[0]] = els[1]
+		}
+	}
+	m[parts[0]] = u
+	return nil
+}
+
+// String formats the union definitions.
+func (m Unions) String() string {
+	var defs []string
+	for name, u := range m {
+		def := name + ":" + u.Discriminator
+		var tags []string
+		for member, tag := range u.Tags {
+			tags = append(tags, member+"="+tag)
+		}
+		sort.Strings(tags)
+		if len(tags) > 0 {
+			def += ":" + strings.Join(tags, ",")
+		}
+		defs = append(defs, def)
+	}
+	sort.Strings(defs)
+	return strings.Join(defs, " ")
+}
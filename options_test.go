@@ -0,0 +1,167 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestTypeNamesSet(t *testing.T) {
+	got := make(TypeNames)
+	for _, name := range []string{"Currency", "billing.Cents", "Currency"} {
+		if err := got.Set(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := got.Set(""); err == nil {
+		t.Error("got no error for an empty type name")
+	}
+	if want := "Currency billing.Cents"; got.String() != want {
+		t.Errorf("String() got %q, want %q", got.String(), want)
+	}
+}
+
+func TestMappingsSet(t *testing.T) {
+	testCases := []struct {
+		input   string
+		want    Mappings
+		wantErr bool
+	}{
+		{
+			input: "github.com/google/uuid.UUID=Uuid.Uuid:Uuid:Uuid.decoder:Uuid.encode",
+			want: Mappings{"github.com/google/uuid.UUID": {
+				Type: "Uuid.Uuid", Import: "Uuid", Decoder: "Uuid.decoder", Encoder: "Uuid.encode",
+			}},
+		},
+		{
+			input: "decimal.Decimal=Float::D.float:E.float",
+			want: Mappings{"decimal.Decimal": {
+				Type: "Float", Decoder: "D.float", Encoder: "E.float",
+			}},
+		},
+		{input: "uuid.UUID", wantErr: true},
+		{input: "=Uuid.Uuid:Uuid:Uuid.decoder:Uuid.encode", wantErr: true},
+		{input: "uuid.UUID=Uuid.Uuid:Uuid:Uuid.decoder", wantErr: true},
+		{input: "uuid.UUID=Uuid.Uuid:Uuid::Uuid.encode", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			got := make(Mappings)
+			err := got.Set(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if diff := deep.Equal(got, tc.want); diff != nil {
+				t.Error("Mappings did not match expectations:\n" + strings.Join(diff, "\n"))
+			}
+			if got.String() != tc.input {
+				t.Errorf("String() got %q, want %q", got.String(), tc.input)
+			}
+		})
+	}
+}
+
+func TestUnionsSet(t *testing.T) {
+	testCases := []struct {
+		input   string
+		want    Unions
+		wantErr bool
+	}{
+		{input: "Event:type", want: Unions{"Event": {Discriminator: "type", Tags: map[string]string{}}}},
+		{
+			input: "api.Event:kind:Clicked=click,Viewed=view",
+			want: Unions{"api.Event": {
+				Discriminator: "kind",
+				Tags:          map[string]string{"Clicked": "click", "Viewed": "view"},
+			}},
+		},
+		{input: "Event", wantErr: true},
+		{input: "Event:", wantErr: true},
+		{input: ":type", wantErr: true},
+		{input: "Event:type:Clicked", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			got := make(Unions)
+			err := got.Set(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if diff := deep.Equal(got, tc.want); diff != nil {
+				t.Error("Unions did not match expectations:\n" + strings.Join(diff, "\n"))
+			}
+			if got.String() != tc.input {
+				t.Errorf("String() got %q, want %q", got.String(), tc.input)
+			}
+		})
+	}
+}
+
+func TestNilPolicySet(t *testing.T) {
+	testCases := []struct {
+		input   string
+		want    NilPolicy
+		wantErr bool
+	}{
+		{input: "empty", want: NilEmpty},
+		{input: "null", want: NilNull},
+		{input: "maybe", want: NilMaybe},
+		{input: "nil", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			var got NilPolicy
+			err := got.Set(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+			if got.String() != tc.input {
+				t.Errorf("String() got %q, want %q", got.String(), tc.input)
+			}
+		})
+	}
+}
+
+func TestInt64PolicySet(t *testing.T) {
+	testCases := []struct {
+		input   string
+		want    Int64Policy
+		wantErr bool
+	}{
+		{input: "int", want: Int64Int},
+		{input: "string", want: Int64String},
+		{input: "warn", want: Int64Warn},
+		{input: "float", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			var got Int64Policy
+			err := got.Set(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+			if got.String() != tc.input {
+				t.Errorf("String() got %q, want %q", got.String(), tc.input)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"go/types"
 	"strings"
+	"unicode"
 
 	"github.com/pkg/errors"
 )
@@ -10,7 +11,12 @@ import (
 // ElmRecord represents an Elm record.
 type ElmRecord struct {
 	name   string
+	root   bool          // Root record of the module.
+	params []*ElmTypeVar // Type parameters of a generic record.
 	Fields []*ElmField
+	// Recursive records refer back to themselves, and are declared as a custom type wrapping the
+	// record of fields.
+	Recursive bool
 }
 
 // Name of this record type.
@@ -23,20 +29,119 @@ func (r *ElmRecord) CamelCasedName() string {
 	return camelCase(r.name)
 }
 
+// FieldsName is the name of the record type alias wrapped by a recursive record.
+func (r *ElmRecord) FieldsName() string {
+	return r.name + "Fields"
+}
+
+// FieldsType returns the record type wrapped by a recursive record, in Elm source format.
+func (r *ElmRecord) FieldsType() string {
+	return elmApply(r.FieldsName(), r.paramExprs()...).Arg()
+}
+
+// TypeParams returns the space-prefixed type variables of a generic record, or empty string.
+func (r *ElmRecord) TypeParams() string {
+	var s string
+	for _, p := range r.params {
+		s += " " + p.name
+	}
+	return s
+}
+
+// DecoderType returns the type of the decoder in Elm source format.  Decoders of generic records
+// take a decoder for each type variable.
+func (r *ElmRecord) DecoderType() string {
+	var s string
+	for _, p := range r.params {
+		s += "D.Decoder " + p.name + " -> "
+	}
+	return s + elmApply("D.Decoder", r.TypeExpr()).String()
+}
+
+// DecoderParams returns the space-prefixed decoder parameters of a generic record, or empty
+// string.
+func (r *ElmRecord) DecoderParams() string {
+	var s string
+	for _, p := range r.params {
+		s += " " + p.DecoderExpr("D").String()
+	}
+	return s
+}
+
+// EncoderType returns the type of the encoder in Elm source format.  Encoders of generic records
+// take an encoder for each type variable.
+func (r *ElmRecord) EncoderType() string {
+	var s string
+	for _, p := range r.params {
+		s += "(" + p.name + " -> E.Value) -> "
+	}
+	return s + r.TypeExpr().String() + " -> E.Value"
+}
+
+// EncoderParams returns the space-prefixed encoder parameters of a generic record, or empty
+// string.
+func (r *ElmRecord) EncoderParams() string {
+	var s string
+	for _, p := range r.params {
+		s += " " + p.EncoderExpr("E").String()
+	}
+	return s
+}
+
 // Decoder for this record type.
 func (r *ElmRecord) Decoder(prefix string) string {
+	if r.root {
+		return "decoder"
+	}
 	return r.CamelCasedName() + "Decoder"
 }
 
 // Encoder for this record type.
 func (r *ElmRecord) Encoder(prefix string) string {
+	if r.root {
+		return "encode"
+	}
 	return "encode" + r.name
 }
 
+// TypeExpr returns the Elm type expression.
+func (r *ElmRecord) TypeExpr() *ElmExpr {
+	return elmApply(r.name, r.paramExprs()...)
+}
+
+// paramExprs returns the type variables of a generic record.
+func (r *ElmRecord) paramExprs() []*ElmExpr {
+	var params []*ElmExpr
+	for _, p := range r.params {
+		params = append(params, p.TypeExpr())
+	}
+	return params
+}
+
+// DecoderExpr returns the Elm JSON decoder expression for this type.
+func (r *ElmRecord) DecoderExpr(prefix string) *ElmExpr {
+	return elmRef(r.Decoder(prefix))
+}
+
+// EncoderExpr returns the Elm JSON encoder expression for this type.
+func (r *ElmRecord) EncoderExpr(prefix string) *ElmExpr {
+	return elmRef(r.Encoder(prefix))
+}
+
+// HasOmittable indicates whether encoders may leave any field out of the JSON object.
+func (r *ElmRecord) HasOmittable() bool {
+	for _, f := range r.Fields {
+		if f.Omittable() {
+			return true
+		}
+	}
+	return false
+}
+
 // Equal tests for equality with another ElmType.
 func (r *ElmRecord) Equal(other ElmType) bool {
 	if o, ok := other.(*ElmRecord); ok {
-		if r.name != o.name {
+		if r.name != o.name || r.Recursive != o.Recursive || len(r.params) != len(o.params) {
 			return false
 		}
 		if len(r.Fields) != len(o.Fields) {
@@ -63,18 +168,22 @@ type ElmField struct {
 	ElmName  string
 	ElmType  ElmType
 	Optional bool
+	// zero is the Go zero value of an omitempty field, when encoders leave out zero values.
+	zero *ElmExpr
+	// zeroDefault fields are not wrapped in a Maybe, absent fields are decoded as zero.
+	zeroDefault bool
 }
 
 // Decoder returns the Elm JSON decoder for this field.
 func (f *ElmField) Decoder(prefix string) string {
-	if f.Optional || f.ElmType.Nullable() {
-		return "(" + prefix + ".nullable " + f.ElmType.Decoder(prefix) + ")"
-	}
-	return f.ElmType.Decoder(prefix)
+	return f.fieldType().DecoderExpr(prefix).Arg()
 }
 
 // Default returns a space-prefixed default value, or empty string.
 func (f *ElmField) Default() string {
+	if f.zeroDefault {
+		return " " + f.zero.Arg()
+	}
 	if f.Optional {
 		return " Nothing"
 	}
@@ -83,10 +192,33 @@ func (f *ElmField) Default() string {
 
 // Encoder reutrns the Elm JSON encoder for this field.
 func (f *ElmField) Encoder(prefix string) string {
-	if f.Optional || f.ElmType.Nullable() {
-		return "maybe " + f.ElmType.Encoder(prefix)
+	encoder := f.fieldType().EncoderExpr(prefix)
+	if _, ok := f.fieldType().(*ElmPointer); ok {
+		return encoder.String()
+	}
+	return encoder.Arg()
+}
+
+// Omittable indicates whether encoders may leave the field out of the JSON object, as encoding/json
+// does for omitempty fields holding empty values.
+func (f *ElmField) Omittable() bool {
+	_, isRecord := f.ElmType.(*ElmRecord)
+	return f.Optional && !(f.zeroDefault && isRecord)
+}
+
+// OptionalEncoder returns the Elm JSON encoder of an omittable field, which is applied to the
+// field value to produce a Maybe key-value pair.  The pair is Nothing when encoding/json would
+// leave the field out.
+func (f *ElmField) OptionalEncoder(prefix string) string {
+	name := elmRef(elmQuote(f.JSONName))
+	if f.zeroDefault {
+		return elmApply("nonZeroField", f.zero, name, f.ElmType.EncoderExpr(prefix)).String()
 	}
-	return f.ElmType.Encoder(prefix)
+	encoder := f.fieldType().(*ElmPointer).elem.EncoderExpr(prefix)
+	if f.zero != nil {
+		return elmApply("Maybe.andThen", elmApply("nonZeroField", f.zero, name, encoder)).String()
+	}
+	return elmApply("optionalField", name, encoder).String()
 }
 
 // Pipeline returns the elm-decode-pipline function for this field.
@@ -99,10 +231,28 @@ func (f *ElmField) Pipeline(prefix string) string {
 
 // TypeDecl returns the type in Elm source format.
 func (f *ElmField) TypeDecl() string {
-	if f.Optional || f.ElmType.Nullable() {
-		return "Maybe " + precedence(f.ElmType.Name())
+	return f.fieldType().TypeExpr().String()
+}
+
+// fieldType returns the type of the field, wrapped in a Maybe when the field may be left out or
+// null.
+func (f *ElmField) fieldType() ElmType {
+	_, isPointer := f.ElmType.(*ElmPointer)
+	if isPointer || f.zeroDefault || !(f.Optional || f.ElmType.Nullable()) {
+		return f.ElmType
+	}
+	return &ElmPointer{elem: f.ElmType}
+}
+
+// zeroValue returns the Elm value of the field in a zero Go struct, or nil if it has none.
+func (f *ElmField) zeroValue() *ElmExpr {
+	if f.zeroDefault {
+		return f.zero
 	}
-	return f.ElmType.Name()
+	if _, ok := f.fieldType().(*ElmPointer); ok {
+		return elmRef("Nothing")
+	}
+	return zeroValue(f.ElmType)
 }
 
 // Equal test for equality with another field.
@@ -115,41 +265,73 @@ func (f *ElmField) Equal(o *ElmField) bool {
 }
 
 func recordFromStruct(resolver *ElmTypeResolver, structDef *types.Struct, typeName string) (*ElmRecord, error) {
-	count := structDef.NumFields()
-	if count == 0 {
-		return nil, errors.Errorf("struct %v had no fields", typeName)
-	}
 	recordName := resolver.renames.ElmName(typeName)
+	if resolver.record != "" {
+		recordName = resolver.record
+	}
+
+	// Apply elm tags, and name the remaining fields.
+	var jfields []jsonField
+	var opts []elmOptions
+	for _, jfield := range typeFields(structDef) {
+		if err := checkMarshalable(jfield.goType); err != nil {
+			return nil, errors.Wrapf(err, "field %s.%s", typeName, jfield.goPath)
+		}
+		elmOpts, err := parseElmTag(jfield.elm)
+		if err != nil {
+			return nil, errors.Wrapf(err, "field %s.%s", typeName, jfield.goPath)
+		}
+		if !elmOpts.skip {
+			jfields = append(jfields, jfield)
+			opts = append(opts, elmOpts)
+		}
+	}
+	elmNames, err := elmFieldNames(typeName, jfields, opts)
+	if err != nil {
+		return nil, err
+	}
 
 	// Convert to our field type.
 	var fields []*ElmField
-	for i := 0; i < structDef.NumFields(); i++ {
-		sfield := structDef.Field(i)
-		stag := structDef.Tag(i)
-		if !sfield.Exported() {
-			continue
-		}
-		goName := sfield.Name()
-		goType := sfield.Type()
-
-		jsonName := goName
-		optional := false
-		if len(stag) > 2 {
-			tagName, tagOpts := parseTag(stag)
-			if tagName != "" {
-				jsonName = tagName
+	for i, jfield := range jfields {
+		goType := jfield.goType
+		elmOpts := opts[i]
+		elmName := elmNames[i]
+		jsonName := jfield.name
+		// Fields promoted through a nil embedded pointer are left out by encoding/json.
+		optional := hasOption("omitempty", jfield.options) || jfield.viaPointer
+		if elmOpts.required || elmOpts.optional {
+			optional = elmOpts.optional
+		}
+		var elmType ElmType
+		var err error
+		if elmOpts.typ == "" {
+			resolver.anonName = recordName + pascalCase(elmName)
+			elmType, err = resolver.Convert(goType)
+			if err != nil {
+				return nil, err
 			}
-			if hasOption("omitempty", tagOpts) {
-				optional = true
+			if hasOption("string", jfield.options) {
+				elmType = resolver.Stringify(goType, elmType)
+			}
+			elmType = resolver.CheckInt64(typeName+"."+jfield.goPath, goType, elmType)
+			if elmOpts.decoder == "" || elmOpts.encoder == "" {
+				resolver.checkStringified(elmType)
 			}
 		}
-
-		// Handle abbrevations.
-		camelCaseName := camelCase(goName)
-		elmName := strings.ToLower(camelCaseName[:1]) + camelCaseName[1:]
-		elmType, err := resolver.Convert(goType)
-		if err != nil {
-			return nil, err
+		elmType = overrideType(elmType, elmOpts)
+		if elmOpts.imp != "" {
+			resolver.imports[elmOpts.imp] = true
+		}
+		var zero *ElmExpr
+		if optional && hasOption("omitempty", jfield.options) &&
+			(resolver.options.OmitZeros || resolver.options.ZeroDefaults) {
+			zero = zeroValue(elmType)
+		}
+		// encoding/json does not leave out empty structs, only their decoders use the zero value.
+		zeroDefault := zero != nil && resolver.options.ZeroDefaults
+		if _, ok := elmType.(*ElmRecord); ok && !zeroDefault {
+			zero = nil
 		}
 		logger.Debug().
 			Str("field", recordName+":"+jsonName).
@@ -157,12 +339,116 @@ func recordFromStruct(resolver *ElmTypeResolver, structDef *types.Struct, typeNa
 			Str("elmType", elmTypeName(elmType)).
 			Msg("Type conversion")
 		fields = append(fields, &ElmField{
-			JSONName: jsonName,
-			ElmName:  elmName,
-			ElmType:  elmType,
-			Optional: optional,
+			JSONName:    jsonName,
+			ElmName:     elmName,
+			ElmType:     elmType,
+			Optional:    optional,
+			zero:        zero,
+			zeroDefault: zeroDefault,
 		})
+		if f := fields[len(fields)-1]; f.Omittable() {
+			if f.zero != nil {
+				resolver.helpers["nonZeroField"] = true
+			} else {
+				resolver.helpers["optionalField"] = true
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return nil, errors.Errorf("struct %v had no fields", typeName)
 	}
 
 	return &ElmRecord{name: recordName, Fields: fields}, nil
 }
+
+// elmFieldNames returns the Elm name of each field, derived from its Go name unless renamed by its
+// elm tag.  Fields promoted from embedded structs may share a Go name, those are named after their
+// distinct JSON keys instead.
+func elmFieldNames(typeName string, jfields []jsonField, opts []elmOptions) ([]string, error) {
+	names := make([]string, len(jfields))
+	count := make(map[string]int)
+	for i, jfield := range jfields {
+		names[i] = opts[i].name
+		if names[i] == "" {
+			// Handle abbrevations.
+			names[i] = unreserved(camelCase(jfield.goName))
+		}
+		count[names[i]]++
+	}
+	for i, jfield := range jfields {
+		if count[names[i]] > 1 && opts[i].name == "" {
+			if name := unreserved(jsonKeyName(jfield.name)); isElmFieldName(name) {
+				names[i] = name
+			}
+		}
+	}
+	owners := make(map[string]string)
+	for i, jfield := range jfields {
+		if other, ok := owners[names[i]]; ok {
+			return nil, errors.Errorf("fields %s.%s and %s.%s share the Elm name %s, "+
+				"rename one with `elm:\"name=...\"`", typeName, other, typeName, jfield.goPath, names[i])
+		}
+		owners[names[i]] = jfield.goPath
+	}
+	return names, nil
+}
+
+// unreserved appends an underscore to Elm keywords, such as `type_`.
+func unreserved(name string) string {
+	if elmKeywords[name] {
+		return name + "_"
+	}
+	return name
+}
+
+// jsonKeyName derives an Elm field name from a JSON object key, such as `userId` from `user_id`.
+func jsonKeyName(key string) string {
+	var name string
+	for _, word := range strings.FieldsFunc(key, func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	}) {
+		name += pascalCase(word)
+	}
+	return camelCase(name)
+}
+
+// overrideType applies the type, decoder and encoder options of an elm tag to the converted type
+// of a field, which is nil when the type option is present.
+func overrideType(elmType ElmType, opts elmOptions) ElmType {
+	if opts.typ == "" && opts.decoder == "" && opts.encoder == "" {
+		return elmType
+	}
+	custom := &ElmExternalType{name: opts.typ, decoder: opts.decoder, encoder: opts.encoder}
+	if _, isPointer := elmType.(*ElmPointer); elmType != nil && elmType.Nullable() && !isPointer {
+		// The custom decoder and encoder replace those handling null.
+		elmType = &ElmPointer{elem: elmType}
+	}
+	if custom.name == "" {
+		custom.name = elmTypeName(elmType)
+	}
+	if custom.decoder == "" {
+		custom.decoder = elmType.DecoderExpr("D").String()
+	}
+	if custom.encoder == "" {
+		custom.encoder = elmType.EncoderExpr("E").String()
+	}
+	return custom
+}
+
+// checkMarshalable rejects the types encoding/json refuses to marshal.
+func checkMarshalable(goType types.Type) error {
+	if p, ok := goType.Underlying().(*types.Pointer); ok {
+		goType = p.Elem()
+	}
+	unsupported := false
+	switch t := goType.Underlying().(type) {
+	case *types.Chan, *types.Signature:
+		unsupported = true
+	case *types.Basic:
+		unsupported = t.Info()&types.IsComplex != 0
+	}
+	if unsupported {
+		return errors.Errorf("encoding/json cannot marshal type %s, tag it `json:\"-\"` to skip", goType)
+	}
+	return nil
+}
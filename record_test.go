@@ -1,11 +1,14 @@
 package main
 
 import (
+	"bytes"
+	"fmt"
 	"strings"
 	"sync"
 	"testing"
 
 	"github.com/go-test/deep"
+	"github.com/rs/zerolog"
 	"golang.org/x/tools/go/packages"
 )
 
@@ -39,6 +42,11 @@ func (p *packageCache) load(path string) (pkgs []*packages.Package, err error) {
 
 var pkgCache = &packageCache{}
 
+func init() {
+	// Compare the unexported fields of ElmRecord and ElmField, such as names and zero values.
+	deep.CompareUnexportedFields = true
+}
+
 const examples = "testdata/examples.go"
 
 func TestRecordFromStructErrors(t *testing.T) {
@@ -54,13 +62,17 @@ func TestRecordFromStructErrors(t *testing.T) {
 		{"DoesNotExist", true},
 		{"AnInterface", true},
 		{"Empty", true},
+		{"ChannelField", true},
+		{"FuncField", true},
+		{"ElmTagConflict", true},
+		{"ElmNameClash", true},
 		{"Strings", false},
 		{"OptionalValues", false},
 	}
 	for _, tt := range tests {
 		structType, err := getStructDef(pkgs, "main", tt.name)
 		if err == nil {
-			_, err = recordFromStruct(NewResolver(make(TypeNamePairs)), structType, tt.name)
+			_, err = recordFromStruct(NewResolver(make(TypeNamePairs), Options{}), structType, tt.name)
 		}
 		got := err != nil
 		if got != tt.errorExpected {
@@ -82,7 +94,7 @@ func TestRecordFromStructAbbrev(t *testing.T) {
 	if err != nil {
 		t.Fatalf("%+v", err)
 	}
-	record, err := recordFromStruct(NewResolver(make(TypeNamePairs)), structType, input)
+	record, err := recordFromStruct(NewResolver(make(TypeNamePairs), Options{}), structType, input)
 	if err != nil {
 		t.Fatalf("%+v", err)
 	}
@@ -130,7 +142,7 @@ func TestRecordFromStructNameConversions(t *testing.T) {
 	if err != nil {
 		t.Fatalf("%+v", err)
 	}
-	got, err := recordFromStruct(NewResolver(make(TypeNamePairs)), structType, name)
+	got, err := recordFromStruct(NewResolver(make(TypeNamePairs), Options{}), structType, name)
 	if err != nil {
 		t.Fatalf("%+v", err)
 	}
@@ -173,7 +185,124 @@ func TestParseStructMultipleNames(t *testing.T) {
 	if err != nil {
 		t.Fatalf("%+v", err)
 	}
-	got, err := recordFromStruct(NewResolver(make(TypeNamePairs)), structType, name)
+	got, err := recordFromStruct(NewResolver(make(TypeNamePairs), Options{}), structType, name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Fatal("ElmRecord struct did not match expectations:\n" + strings.Join(diff, "\n"))
+	}
+	if !got.Equal(want) {
+		t.Error("ElmRecord struct did not match expectations, likely in an ElmType field.")
+	}
+}
+
+func TestRecordFromStructSkippedFields(t *testing.T) {
+	pkgs, err := pkgCache.load(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "SkippedFields"
+	want := &ElmRecord{
+		name: name,
+		Fields: []*ElmField{
+			{
+				JSONName: "Kept",
+				ElmName:  "kept",
+				ElmType:  elmString,
+			},
+			{
+				JSONName: "-",
+				ElmName:  "dash",
+				ElmType:  elmString,
+			},
+			{
+				JSONName: "Invalid",
+				ElmName:  "invalid",
+				ElmType:  elmString,
+			},
+		},
+	}
+	structType, err := getStructDef(pkgs, "main", name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	got, err := recordFromStruct(NewResolver(make(TypeNamePairs), Options{}), structType, name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Fatal("ElmRecord struct did not match expectations:\n" + strings.Join(diff, "\n"))
+	}
+	if !got.Equal(want) {
+		t.Error("ElmRecord struct did not match expectations, likely in an ElmType field.")
+	}
+}
+
+func TestRecordFromStructEmbedded(t *testing.T) {
+	pkgs, err := pkgCache.load(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "EmbeddedStructs"
+	innerType := &ElmRecord{
+		name: "InnerStruct",
+		Fields: []*ElmField{
+			{
+				JSONName: "Value",
+				ElmName:  "value",
+				ElmType:  elmString,
+			},
+		},
+	}
+	want := &ElmRecord{
+		name: name,
+		Fields: []*ElmField{
+			{
+				JSONName: "id",
+				ElmName:  "id",
+				ElmType:  elmInt,
+			},
+			{
+				JSONName: "created",
+				ElmName:  "created",
+				ElmType:  elmString,
+			},
+			{
+				JSONName: "Email",
+				ElmName:  "email",
+				ElmType:  elmString,
+				Optional: true,
+			},
+			{
+				JSONName: "Updated",
+				ElmName:  "updated",
+				ElmType:  elmString,
+			},
+			{
+				JSONName: "Labels",
+				ElmName:  "labels",
+				ElmType:  &ElmList{elem: elmString},
+			},
+			{
+				JSONName: "inner",
+				ElmName:  "innerStruct",
+				ElmType:  innerType,
+			},
+			{
+				JSONName: "Name",
+				ElmName:  "name",
+				ElmType:  elmString,
+			},
+		},
+	}
+	structType, err := getStructDef(pkgs, "main", name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	got, err := recordFromStruct(NewResolver(make(TypeNamePairs), Options{}), structType, name)
 	if err != nil {
 		t.Fatalf("%+v", err)
 	}
@@ -185,6 +314,41 @@ func TestParseStructMultipleNames(t *testing.T) {
 	}
 }
 
+func TestRecordFromStructPromotedNames(t *testing.T) {
+	pkgs, err := pkgCache.load(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	structType, err := getStructDef(pkgs, "main", "PromotedIDs")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	got, err := recordFromStruct(NewResolver(make(TypeNamePairs), Options{}), structType, "PromotedIDs")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	var gotNames []string
+	for _, f := range got.Fields {
+		gotNames = append(gotNames, f.JSONName+":"+f.ElmName)
+	}
+	wantNames := []string{"id:id", "created:created", "user_id:userId"}
+	if diff := deep.Equal(gotNames, wantNames); diff != nil {
+		t.Error("field names did not match expectations:\n" + strings.Join(diff, "\n"))
+	}
+
+	// Clashes name the fields by their path through embedded structs.
+	structType, err = getStructDef(pkgs, "main", "PromotedClash")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	_, err = recordFromStruct(NewResolver(make(TypeNamePairs), Options{}), structType, "PromotedClash")
+	want := "fields PromotedClash.BaseModel.ID and PromotedClash.Key share the Elm name id"
+	if err == nil || !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+}
+
 func TestRecordFromStructTypeConversions(t *testing.T) {
 	pkgs, err := pkgCache.load(examples)
 	if err != nil {
@@ -226,7 +390,7 @@ func TestRecordFromStructTypeConversions(t *testing.T) {
 	if err != nil {
 		t.Fatalf("%+v", err)
 	}
-	got, err := recordFromStruct(NewResolver(make(TypeNamePairs)), structType, name)
+	got, err := recordFromStruct(NewResolver(make(TypeNamePairs), Options{}), structType, name)
 	if err != nil {
 		t.Fatalf("%+v", err)
 	}
@@ -269,7 +433,7 @@ func TestRecordFromStructSlices(t *testing.T) {
 	if err != nil {
 		t.Fatalf("%+v", err)
 	}
-	got, err := recordFromStruct(NewResolver(make(TypeNamePairs)), structType, name)
+	got, err := recordFromStruct(NewResolver(make(TypeNamePairs), Options{}), structType, name)
 	if err != nil {
 		t.Fatalf("%+v", err)
 	}
@@ -281,33 +445,40 @@ func TestRecordFromStructSlices(t *testing.T) {
 	}
 }
 
-func TestRecordFromStructOptionals(t *testing.T) {
+func TestRecordFromStructMaps(t *testing.T) {
 	pkgs, err := pkgCache.load(examples)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	name := "OptionalValues"
+	name := "MapTypes"
+	innerType := &ElmRecord{
+		name: "InnerStruct",
+		Fields: []*ElmField{
+			{
+				JSONName: "Value",
+				ElmName:  "value",
+				ElmType:  elmString,
+			},
+		},
+	}
 	want := &ElmRecord{
 		name: name,
 		Fields: []*ElmField{
 			{
-				JSONName: "opt-string",
-				ElmName:  "optString",
-				ElmType:  elmString,
-				Optional: true,
+				JSONName: "Counts",
+				ElmName:  "counts",
+				ElmType:  &ElmDict{key: elmString, elem: elmInt},
 			},
 			{
-				JSONName: "OptInt",
-				ElmName:  "optInt",
-				ElmType:  elmInt,
-				Optional: true,
+				JSONName: "Lists",
+				ElmName:  "lists",
+				ElmType:  &ElmDict{key: elmString, elem: &ElmList{elem: elmString}},
 			},
 			{
-				JSONName: "OptBool",
-				ElmName:  "optBool",
-				ElmType:  elmBool,
-				Optional: true,
+				JSONName: "Structs",
+				ElmName:  "structs",
+				ElmType:  &ElmDict{key: elmString, elem: innerType},
 			},
 		},
 	}
@@ -315,7 +486,7 @@ func TestRecordFromStructOptionals(t *testing.T) {
 	if err != nil {
 		t.Fatalf("%+v", err)
 	}
-	got, err := recordFromStruct(NewResolver(make(TypeNamePairs)), structType, name)
+	got, err := recordFromStruct(NewResolver(make(TypeNamePairs), Options{}), structType, name)
 	if err != nil {
 		t.Fatalf("%+v", err)
 	}
@@ -327,46 +498,35 @@ func TestRecordFromStructOptionals(t *testing.T) {
 	}
 }
 
-func TestRecordFromStructNullables(t *testing.T) {
+func TestRecordFromStructKeyedMaps(t *testing.T) {
 	pkgs, err := pkgCache.load(examples)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	name := "NullableValues"
-	innerType := &ElmRecord{
-		name: "InnerStruct",
-		Fields: []*ElmField{
-			{
-				JSONName: "Value",
-				ElmName:  "value",
-				ElmType:  elmString,
-			},
-		},
-	}
+	name := "KeyedMaps"
 	want := &ElmRecord{
 		name: name,
 		Fields: []*ElmField{
 			{
-				JSONName: "NullString",
-				ElmName:  "nullString",
-				ElmType:  &ElmPointer{elem: elmString},
+				JSONName: "ByInt",
+				ElmName:  "byInt",
+				ElmType:  &ElmDict{key: elmInt, elem: elmString},
 			},
 			{
-				JSONName: "OptNullString",
-				ElmName:  "optNullString",
-				ElmType:  &ElmPointer{elem: elmString},
-				Optional: true,
+				JSONName: "ByUint",
+				ElmName:  "byUint",
+				ElmType:  &ElmDict{key: elmUint, elem: elmBool},
 			},
 			{
-				JSONName: "NullInt",
-				ElmName:  "nullInt",
-				ElmType:  &ElmPointer{elem: elmInt},
+				JSONName: "ByText",
+				ElmName:  "byText",
+				ElmType:  &ElmDict{key: elmString, elem: elmInt},
 			},
 			{
-				JSONName: "NullStruct",
-				ElmName:  "nullStruct",
-				ElmType:  &ElmPointer{elem: innerType},
+				JSONName: "ByNamed",
+				ElmName:  "byNamed",
+				ElmType:  &ElmDict{key: &ElmWrapper{name: "NamedKey", basic: elmString}, elem: elmFloat},
 			},
 		},
 	}
@@ -374,7 +534,7 @@ func TestRecordFromStructNullables(t *testing.T) {
 	if err != nil {
 		t.Fatalf("%+v", err)
 	}
-	got, err := recordFromStruct(NewResolver(make(TypeNamePairs)), structType, name)
+	got, err := recordFromStruct(NewResolver(make(TypeNamePairs), Options{}), structType, name)
 	if err != nil {
 		t.Fatalf("%+v", err)
 	}
@@ -386,41 +546,52 @@ func TestRecordFromStructNullables(t *testing.T) {
 	}
 }
 
-func TestRecordFromStructNested(t *testing.T) {
+func TestRecordFromStructStringEnums(t *testing.T) {
 	pkgs, err := pkgCache.load(examples)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	name := "NestedStructs"
-	innerType := &ElmRecord{
-		name: "InnerStruct",
-		Fields: []*ElmField{
-			{
-				JSONName: "Value",
-				ElmName:  "value",
-				ElmType:  elmString,
-			},
+	name := "StringEnums"
+	statusType := &ElmEnum{
+		name:  "Status",
+		basic: elmString,
+		Values: []*ElmEnumValue{
+			{Constructor: "Active", GoName: "StatusActive", Literal: `"active"`},
+			{Constructor: "Inactive", GoName: "StatusInactive", Literal: `"inactive"`},
+			{Constructor: "Archived", GoName: "StatusArchived", Literal: `"archived"`},
+		},
+	}
+	roleType := &ElmEnum{
+		name:  "Role",
+		basic: elmString,
+		Values: []*ElmEnumValue{
+			{Constructor: "RoleActive", GoName: "RoleActive", Literal: `"active-role"`},
+			{Constructor: "Admin", GoName: "RoleAdmin", Literal: `"admin"`},
 		},
 	}
-
 	want := &ElmRecord{
 		name: name,
 		Fields: []*ElmField{
 			{
-				JSONName: "OuterName",
-				ElmName:  "outerName",
-				ElmType:  elmString,
+				JSONName: "Status",
+				ElmName:  "status",
+				ElmType:  statusType,
 			},
 			{
-				JSONName: "InnerValue1",
-				ElmName:  "innerValue1",
-				ElmType:  innerType,
+				JSONName: "Role",
+				ElmName:  "role",
+				ElmType:  &ElmPointer{elem: roleType},
 			},
 			{
-				JSONName: "InnerValue2",
-				ElmName:  "innerValue2",
-				ElmType:  innerType,
+				JSONName: "History",
+				ElmName:  "history",
+				ElmType:  &ElmList{elem: statusType},
+			},
+			{
+				JSONName: "ByStatus",
+				ElmName:  "byStatus",
+				ElmType:  &ElmDict{key: statusType, elem: elmInt},
 			},
 		},
 	}
@@ -428,61 +599,1058 @@ func TestRecordFromStructNested(t *testing.T) {
 	if err != nil {
 		t.Fatalf("%+v", err)
 	}
-	got, err := recordFromStruct(NewResolver(make(TypeNamePairs)), structType, name)
+	got, err := recordFromStruct(NewResolver(make(TypeNamePairs), Options{}), structType, name)
 	if err != nil {
 		t.Fatalf("%+v", err)
 	}
 	if diff := deep.Equal(got, want); diff != nil {
 		t.Fatal("ElmRecord struct did not match expectations:\n" + strings.Join(diff, "\n"))
 	}
-	if innerRecord, ok := got.Fields[1].ElmType.(*ElmRecord); ok {
-		t.Logf("innerRecord: %#v", innerRecord)
-		for i, f := range innerRecord.Fields {
-			t.Logf("innerRecord[%v]: %#v", i, f)
-			t.Logf("innerRecord[%v].ElmType: %s", i, f.ElmType.Name())
-		}
-	} else {
-		t.Errorf("Fields[1].ElmType was %T, not *ElmRecord", got.Fields[1].ElmType)
-	}
-
 	if !got.Equal(want) {
 		t.Error("ElmRecord struct did not match expectations, likely in an ElmType field.")
 	}
 }
 
-func TestRecordFromStructNestedRenames(t *testing.T) {
+func TestResolveRootEnumRecordClash(t *testing.T) {
 	pkgs, err := pkgCache.load(examples)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	renames := make(TypeNamePairs)
-	renames.Add("NestedStructs:NewOuter")
-	renames.Add("innerStruct:NewInner")
+	// The enum constructor is prefixed whichever type is converted first.
+	for _, name := range []string{"Shipment", "Parcel"} {
+		t.Run(name, func(t *testing.T) {
+			namedType, err := getNamedStruct(pkgs, "main", name)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			resolver := NewResolver(make(TypeNamePairs), Options{})
+			if _, err := resolver.ResolveRoot(namedType); err != nil {
+				t.Fatalf("%+v", err)
+			}
+			var got []string
+			for _, v := range resolver.CachedEnums()[0].Values {
+				got = append(got, v.Constructor)
+			}
+			want := []string{"KindAddress", "Other"}
+			if diff := deep.Equal(got, want); diff != nil {
+				t.Error("constructors did not match expectations:\n" + strings.Join(diff, "\n"))
+			}
+			if records := resolver.CachedRecords(); len(records) != 1 || records[0].Name() != "Address" {
+				t.Errorf("got records %v, want Address", records)
+			}
+		})
+	}
+}
 
-	name := "NestedStructs"
+func TestRecordFromStructIntEnums(t *testing.T) {
+	pkgs, err := pkgCache.load(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "IntEnums"
+	priorityType := &ElmEnum{
+		name:  "Priority",
+		basic: elmInt,
+		Label: true,
+		Values: []*ElmEnumValue{
+			{Constructor: "Low", GoName: "PriorityLow", Literal: "0"},
+			{Constructor: "Medium", GoName: "PriorityMedium", Literal: "1"},
+			{Constructor: "High", GoName: "PriorityHigh", Literal: "2"},
+			{Constructor: "Urgent", GoName: "PriorityUrgent", Literal: "4"},
+			{Constructor: "None", GoName: "PriorityNone", Literal: "-1"},
+		},
+	}
+	levelType := &ElmEnum{
+		name:  "Level",
+		basic: elmInt,
+		Values: []*ElmEnumValue{
+			{Constructor: "Debug", GoName: "LevelDebug", Literal: "1"},
+			{Constructor: "Info", GoName: "LevelInfo", Literal: "2"},
+		},
+	}
+	want := &ElmRecord{
+		name: name,
+		Fields: []*ElmField{
+			{
+				JSONName: "Priority",
+				ElmName:  "priority",
+				ElmType:  priorityType,
+			},
+			{
+				JSONName: "Level",
+				ElmName:  "level",
+				ElmType:  &ElmPointer{elem: levelType},
+			},
+			{
+				JSONName: "ByPriority",
+				ElmName:  "byPriority",
+				ElmType:  &ElmDict{key: priorityType, elem: elmString},
+			},
+		},
+	}
 	structType, err := getStructDef(pkgs, "main", name)
 	if err != nil {
 		t.Fatalf("%+v", err)
 	}
-	got, err := recordFromStruct(NewResolver(renames), structType, name)
+	got, err := recordFromStruct(NewResolver(make(TypeNamePairs), Options{}), structType, name)
 	if err != nil {
 		t.Fatalf("%+v", err)
 	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Fatal("ElmRecord struct did not match expectations:\n" + strings.Join(diff, "\n"))
+	}
+	if !got.Equal(want) {
+		t.Error("ElmRecord struct did not match expectations, likely in an ElmType field.")
+	}
+}
 
-	wantName := "NewOuter"
-	gotName := got.name
-	if gotName != wantName {
-		t.Errorf("got name %q, want %q", gotName, wantName)
+func TestRecordFromStructNamedBasics(t *testing.T) {
+	pkgs, err := pkgCache.load(examples)
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	wantName = "NewInner"
-	gotType, ok := got.Fields[1].ElmType.(*ElmRecord)
-	if !ok {
-		t.Fatalf("want type *ElmRecord, got %T", got.Fields[1].ElmType)
+	name := "NamedBasics"
+	for _, opaque := range []bool{false, true} {
+		userID := &ElmWrapper{name: "UserId", basic: elmString, opaque: opaque}
+		cents := &ElmWrapper{name: "Cents", basic: elmInt, opaque: opaque}
+		ratio := &ElmWrapper{name: "Ratio", basic: elmFloat, opaque: opaque}
+		want := &ElmRecord{
+			name: name,
+			Fields: []*ElmField{
+				{
+					JSONName: "ID",
+					ElmName:  "id",
+					ElmType:  userID,
+				},
+				{
+					JSONName: "Friends",
+					ElmName:  "friends",
+					ElmType:  &ElmList{elem: userID},
+				},
+				{
+					JSONName: "Balance",
+					ElmName:  "balance",
+					ElmType:  &ElmPointer{elem: cents},
+				},
+				{
+					JSONName: "Ratio",
+					ElmName:  "ratio",
+					ElmType:  ratio,
+				},
+				{
+					JSONName: "ByUser",
+					ElmName:  "byUser",
+					ElmType:  &ElmDict{key: userID, elem: cents},
+				},
+			},
+		}
+		structType, err := getStructDef(pkgs, "main", name)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		got, err := recordFromStruct(NewResolver(make(TypeNamePairs), Options{Opaque: opaque}),
+			structType, name)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if diff := deep.Equal(got, want); diff != nil {
+			t.Fatal("ElmRecord struct did not match expectations:\n" + strings.Join(diff, "\n"))
+		}
+		if !got.Equal(want) {
+			t.Errorf("opaque %v: ElmRecord struct did not match expectations, likely in an ElmType field.",
+				opaque)
+		}
 	}
-	gotName = gotType.name
-	if gotName != wantName {
-		t.Errorf("got name %q, want %q", gotName, wantName)
+}
+
+func TestRecordFromStructTimes(t *testing.T) {
+	pkgs, err := pkgCache.load(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "TimeTypes"
+	want := &ElmRecord{
+		name: name,
+		Fields: []*ElmField{
+			{
+				JSONName: "Created",
+				ElmName:  "created",
+				ElmType:  elmPosix,
+			},
+			{
+				JSONName: "Updated",
+				ElmName:  "updated",
+				ElmType:  &ElmPointer{elem: elmPosix},
+			},
+			{
+				JSONName: "History",
+				ElmName:  "history",
+				ElmType:  &ElmList{elem: elmPosix},
+			},
+			{
+				JSONName: "ByName",
+				ElmName:  "byName",
+				ElmType:  &ElmDict{key: elmString, elem: elmPosix},
+			},
+		},
+	}
+	structType, err := getStructDef(pkgs, "main", name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	got, err := recordFromStruct(NewResolver(make(TypeNamePairs), Options{}), structType, name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Fatal("ElmRecord struct did not match expectations:\n" + strings.Join(diff, "\n"))
+	}
+	if !got.Equal(want) {
+		t.Error("ElmRecord struct did not match expectations, likely in an ElmType field.")
+	}
+}
+
+func TestRecordFromStructOptionals(t *testing.T) {
+	pkgs, err := pkgCache.load(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "OptionalValues"
+	want := &ElmRecord{
+		name: name,
+		Fields: []*ElmField{
+			{
+				JSONName: "opt-string",
+				ElmName:  "optString",
+				ElmType:  elmString,
+				Optional: true,
+			},
+			{
+				JSONName: "OptInt",
+				ElmName:  "optInt",
+				ElmType:  elmInt,
+				Optional: true,
+			},
+			{
+				JSONName: "OptBool",
+				ElmName:  "optBool",
+				ElmType:  elmBool,
+				Optional: true,
+			},
+		},
+	}
+	structType, err := getStructDef(pkgs, "main", name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	got, err := recordFromStruct(NewResolver(make(TypeNamePairs), Options{}), structType, name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Fatal("ElmRecord struct did not match expectations:\n" + strings.Join(diff, "\n"))
+	}
+	if !got.Equal(want) {
+		t.Error("ElmRecord struct did not match expectations, likely in an ElmType field.")
+	}
+}
+
+func TestRecordFromStructNullables(t *testing.T) {
+	pkgs, err := pkgCache.load(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "NullableValues"
+	innerType := &ElmRecord{
+		name: "InnerStruct",
+		Fields: []*ElmField{
+			{
+				JSONName: "Value",
+				ElmName:  "value",
+				ElmType:  elmString,
+			},
+		},
+	}
+	want := &ElmRecord{
+		name: name,
+		Fields: []*ElmField{
+			{
+				JSONName: "NullString",
+				ElmName:  "nullString",
+				ElmType:  &ElmPointer{elem: elmString},
+			},
+			{
+				JSONName: "OptNullString",
+				ElmName:  "optNullString",
+				ElmType:  &ElmPointer{elem: elmString},
+				Optional: true,
+			},
+			{
+				JSONName: "NullInt",
+				ElmName:  "nullInt",
+				ElmType:  &ElmPointer{elem: elmInt},
+			},
+			{
+				JSONName: "NullStruct",
+				ElmName:  "nullStruct",
+				ElmType:  &ElmPointer{elem: innerType},
+			},
+		},
+	}
+	structType, err := getStructDef(pkgs, "main", name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	got, err := recordFromStruct(NewResolver(make(TypeNamePairs), Options{}), structType, name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Fatal("ElmRecord struct did not match expectations:\n" + strings.Join(diff, "\n"))
+	}
+	if !got.Equal(want) {
+		t.Error("ElmRecord struct did not match expectations, likely in an ElmType field.")
+	}
+}
+
+func TestRecordFromStructNested(t *testing.T) {
+	pkgs, err := pkgCache.load(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "NestedStructs"
+	innerType := &ElmRecord{
+		name: "InnerStruct",
+		Fields: []*ElmField{
+			{
+				JSONName: "Value",
+				ElmName:  "value",
+				ElmType:  elmString,
+			},
+		},
+	}
+
+	want := &ElmRecord{
+		name: name,
+		Fields: []*ElmField{
+			{
+				JSONName: "OuterName",
+				ElmName:  "outerName",
+				ElmType:  elmString,
+			},
+			{
+				JSONName: "InnerValue1",
+				ElmName:  "innerValue1",
+				ElmType:  innerType,
+			},
+			{
+				JSONName: "InnerValue2",
+				ElmName:  "innerValue2",
+				ElmType:  innerType,
+			},
+		},
+	}
+	structType, err := getStructDef(pkgs, "main", name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	got, err := recordFromStruct(NewResolver(make(TypeNamePairs), Options{}), structType, name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Fatal("ElmRecord struct did not match expectations:\n" + strings.Join(diff, "\n"))
+	}
+	if innerRecord, ok := got.Fields[1].ElmType.(*ElmRecord); ok {
+		t.Logf("innerRecord: %#v", innerRecord)
+		for i, f := range innerRecord.Fields {
+			t.Logf("innerRecord[%v]: %#v", i, f)
+			t.Logf("innerRecord[%v].ElmType: %s", i, elmTypeName(f.ElmType))
+		}
+	} else {
+		t.Errorf("Fields[1].ElmType was %T, not *ElmRecord", got.Fields[1].ElmType)
+	}
+
+	if !got.Equal(want) {
+		t.Error("ElmRecord struct did not match expectations, likely in an ElmType field.")
+	}
+}
+
+func TestRecordFromStructNestedRenames(t *testing.T) {
+	pkgs, err := pkgCache.load(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	renames := make(TypeNamePairs)
+	renames.Add("NestedStructs:NewOuter")
+	renames.Add("innerStruct:NewInner")
+
+	name := "NestedStructs"
+	structType, err := getStructDef(pkgs, "main", name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	got, err := recordFromStruct(NewResolver(renames, Options{}), structType, name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	wantName := "NewOuter"
+	gotName := got.name
+	if gotName != wantName {
+		t.Errorf("got name %q, want %q", gotName, wantName)
+	}
+
+	wantName = "NewInner"
+	gotType, ok := got.Fields[1].ElmType.(*ElmRecord)
+	if !ok {
+		t.Fatalf("want type *ElmRecord, got %T", got.Fields[1].ElmType)
+	}
+	gotName = gotType.name
+	if gotName != wantName {
+		t.Errorf("got name %q, want %q", gotName, wantName)
+	}
+}
+
+func TestResolveRootRecursive(t *testing.T) {
+	pkgs, err := pkgCache.load(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		wantRecursive []string
+		wantNested    []string
+	}{
+		{name: "Thread", wantRecursive: []string{"Comment"}, wantNested: []string{"Comment"}},
+		{name: "Comment", wantRecursive: []string{"Comment"}},
+		{name: "Category", wantRecursive: []string{"Category"}, wantNested: []string{"Product"}},
+		{name: "Product", wantRecursive: []string{"Product", "Category"}, wantNested: []string{"Category"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namedType, err := getNamedStruct(pkgs, "main", tt.name)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			resolver := NewResolver(make(TypeNamePairs), Options{})
+			root, err := resolver.ResolveRoot(namedType)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			var gotRecursive, gotNested []string
+			for _, record := range append([]*ElmRecord{root}, resolver.CachedRecords()...) {
+				if record.Recursive {
+					gotRecursive = append(gotRecursive, record.Name())
+				}
+				if record != root {
+					gotNested = append(gotNested, record.Name())
+				}
+			}
+			if diff := deep.Equal(gotRecursive, tt.wantRecursive); diff != nil {
+				t.Error("recursive records did not match expectations:\n" + strings.Join(diff, "\n"))
+			}
+			if diff := deep.Equal(gotNested, tt.wantNested); diff != nil {
+				t.Error("nested records did not match expectations:\n" + strings.Join(diff, "\n"))
+			}
+		})
+	}
+}
+
+func TestResolveRootNameClashes(t *testing.T) {
+	pkgs, err := pkgCache.load(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		renames  []string
+		want     []string
+		wantNote bool
+		wantErr  bool
+	}{
+		{want: []string{"Address", "BillingAddress"}, wantNote: true},
+		{renames: []string{"billing.Address:Invoice"}, want: []string{"Address", "Invoice"}},
+		{renames: []string{"Address:Location"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.renames, ","), func(t *testing.T) {
+			renames := make(TypeNamePairs)
+			for _, rename := range tt.renames {
+				renames.Add(rename)
+			}
+			namedType, err := getNamedStruct(pkgs, "main", "Addresses")
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			resolver := NewResolver(renames, Options{})
+			_, err = resolver.ResolveRoot(namedType)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var got []string
+			for _, record := range resolver.CachedRecords() {
+				got = append(got, record.Name())
+			}
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Error("nested records did not match expectations:\n" + strings.Join(diff, "\n"))
+			}
+			if gotNote := len(resolver.Notes()) > 0; gotNote != tt.wantNote {
+				t.Errorf("got notes %q, want notes %v", resolver.Notes(), tt.wantNote)
+			}
+		})
+	}
+}
+
+func TestRecordFromStructGenerics(t *testing.T) {
+	pkgs, err := pkgCache.load(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "GenericTypes"
+	structType, err := getStructDef(pkgs, "main", name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	resolver := NewResolver(make(TypeNamePairs), Options{})
+	got, err := recordFromStruct(resolver, structType, name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	wantTypes := []string{"Page String", "Page Int", "Envelope (Page Address) (Dict String Int)",
+		"Tree Float"}
+	for i, want := range wantTypes {
+		if _, ok := got.Fields[i].ElmType.(*ElmApplied); !ok {
+			t.Errorf("Fields[%v].ElmType was %T, not *ElmApplied", i, got.Fields[i].ElmType)
+		}
+		if gotType := got.Fields[i].TypeDecl(); gotType != want {
+			t.Errorf("Fields[%v] got type %q, want %q", i, gotType, want)
+		}
+	}
+
+	// Every instantiation shares the generic record.
+	var gotRecords []string
+	for _, record := range resolver.CachedRecords() {
+		gotRecords = append(gotRecords, record.Name()+record.TypeParams())
+	}
+	wantRecords := []string{"Page a", "Address", "Envelope a b", "Tree a"}
+	if diff := deep.Equal(gotRecords, wantRecords); diff != nil {
+		t.Error("nested records did not match expectations:\n" + strings.Join(diff, "\n"))
+	}
+	for _, record := range resolver.CachedRecords() {
+		if wantRecursive := record.name == "Tree"; record.Recursive != wantRecursive {
+			t.Errorf("%s got Recursive %v, want %v", record.name, record.Recursive, wantRecursive)
+		}
+	}
+}
+
+func TestRecordFromStructUnions(t *testing.T) {
+	pkgs, err := pkgCache.load(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clicked := &ElmRecord{
+		name: "Clicked",
+		Fields: []*ElmField{
+			{JSONName: "type", ElmName: "type_", ElmType: elmString},
+			{JSONName: "X", ElmName: "x", ElmType: elmInt},
+			{JSONName: "Y", ElmName: "y", ElmType: elmInt},
+		},
+	}
+	viewed := &ElmRecord{
+		name: "Viewed",
+		Fields: []*ElmField{
+			{JSONName: "type", ElmName: "type_", ElmType: elmString},
+			{JSONName: "Page", ElmName: "page", ElmType: elmString},
+		},
+	}
+	event := &ElmUnion{
+		name:          "Event",
+		Discriminator: "type",
+		Variants: []*ElmVariant{
+			{Constructor: "EventClicked", Payload: clicked, Tag: `"Clicked"`},
+			{Constructor: "EventViewed", Payload: viewed, Tag: `"view"`},
+		},
+	}
+	name := "EventLog"
+	want := &ElmRecord{
+		name: name,
+		Fields: []*ElmField{
+			{JSONName: "Latest", ElmName: "latest", ElmType: event},
+			{JSONName: "Events", ElmName: "events", ElmType: &ElmList{elem: &ElmPointer{elem: event}}},
+		},
+	}
+	structType, err := getStructDef(pkgs, "main", name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	options := Options{Unions: make(Unions)}
+	if err := options.Unions.Set("Event:type:Viewed=view"); err != nil {
+		t.Fatal(err)
+	}
+	resolver := NewResolver(make(TypeNamePairs), options)
+	got, err := recordFromStruct(resolver, structType, name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Fatal("ElmRecord struct did not match expectations:\n" + strings.Join(diff, "\n"))
+	}
+	if !got.Equal(want) {
+		t.Error("ElmRecord struct did not match expectations, likely in an ElmType field.")
+	}
+
+	// Without configuration, the interface is an error.
+	_, err = recordFromStruct(NewResolver(make(TypeNamePairs), Options{}), structType, name)
+	if err == nil {
+		t.Error("got no error for unconfigured union, want error")
+	}
+
+	// Members used outside the union keep their discriminator field.
+	structType, err = getStructDef(pkgs, "main", "ClickLog")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	got, err = recordFromStruct(NewResolver(make(TypeNamePairs), options), structType, "ClickLog")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if diff := deep.Equal(got.Fields[0].ElmType, clicked); diff != nil {
+		t.Error("Clicked record did not match expectations:\n" + strings.Join(diff, "\n"))
+	}
+}
+
+func TestRecordFromStructValues(t *testing.T) {
+	pkgs, err := pkgCache.load(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "ValueTypes"
+	want := &ElmRecord{
+		name: name,
+		Fields: []*ElmField{
+			{JSONName: "Raw", ElmName: "raw", ElmType: elmValue},
+			{JSONName: "Any", ElmName: "any", ElmType: elmValue},
+			{JSONName: "Meta", ElmName: "meta", ElmType: &ElmDict{key: elmString, elem: elmValue}},
+			{JSONName: "List", ElmName: "list", ElmType: &ElmList{elem: elmValue}},
+		},
+	}
+	structType, err := getStructDef(pkgs, "main", name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	got, err := recordFromStruct(NewResolver(make(TypeNamePairs), Options{}), structType, name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Fatal("ElmRecord struct did not match expectations:\n" + strings.Join(diff, "\n"))
+	}
+	if !got.Equal(want) {
+		t.Error("ElmRecord struct did not match expectations, likely in an ElmType field.")
+	}
+}
+
+func TestRecordFromStructStringified(t *testing.T) {
+	pkgs, err := pkgCache.load(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	level := &ElmEnum{
+		name:  "Level",
+		basic: elmInt,
+		Values: []*ElmEnumValue{
+			{Constructor: "Debug", GoName: "LevelDebug", Literal: "1"},
+			{Constructor: "Info", GoName: "LevelInfo", Literal: "2"},
+		},
+	}
+	name := "Stringified"
+	want := &ElmRecord{
+		name: name,
+		Fields: []*ElmField{
+			{JSONName: "ID", ElmName: "id", ElmType: &ElmStringified{elem: elmInt}},
+			{JSONName: "Ratio", ElmName: "ratio", ElmType: &ElmStringified{elem: elmFloat}},
+			{JSONName: "Enabled", ElmName: "enabled", ElmType: &ElmStringified{elem: elmBool}},
+			{JSONName: "Name", ElmName: "name", ElmType: &ElmStringified{elem: elmString}},
+			{
+				JSONName: "Parent",
+				ElmName:  "parent",
+				ElmType:  &ElmPointer{elem: &ElmStringified{elem: elmInt}},
+			},
+			{JSONName: "Level", ElmName: "level", ElmType: &ElmStringified{elem: level}},
+			{JSONName: "Tags", ElmName: "tags", ElmType: &ElmList{elem: elmInt}},
+		},
+	}
+	structType, err := getStructDef(pkgs, "main", name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	got, err := recordFromStruct(NewResolver(make(TypeNamePairs), Options{}), structType, name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if diff := deep.Equal(got, want); diff != nil {
+		t.Fatal("ElmRecord struct did not match expectations:\n" + strings.Join(diff, "\n"))
+	}
+	if !got.Equal(want) {
+		t.Error("ElmRecord struct did not match expectations, likely in an ElmType field.")
+	}
+}
+
+func TestRecordFromStructInt64(t *testing.T) {
+	pkgs, err := pkgCache.load(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "BigInts"
+	tests := []struct {
+		policy       Int64Policy
+		id, parent   ElmType
+		wantWarnings int
+	}{
+		{Int64Int, &ElmStringified{elem: elmInt}, &ElmStringified{elem: elmUint}, 0},
+		{Int64String, elmString, elmString, 2},
+		{Int64Warn, &ElmStringified{elem: elmInt}, &ElmStringified{elem: elmUint}, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			saved := logger
+			logger = zerolog.New(buf).Level(zerolog.WarnLevel)
+			defer func() { logger = saved }()
+
+			want := &ElmRecord{
+				name: name,
+				Fields: []*ElmField{
+					{JSONName: "ID", ElmName: "id", ElmType: tt.id},
+					{JSONName: "Parent", ElmName: "parent", ElmType: &ElmPointer{elem: tt.parent}},
+					{JSONName: "Count", ElmName: "count", ElmType: elmInt},
+					{JSONName: "Counts", ElmName: "counts", ElmType: &ElmList{elem: elmUint}},
+					{JSONName: "Small", ElmName: "small", ElmType: elmUint},
+				},
+			}
+			structType, err := getStructDef(pkgs, "main", name)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			resolver := NewResolver(make(TypeNamePairs), Options{Int64: tt.policy})
+			got, err := recordFromStruct(resolver, structType, name)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			if diff := deep.Equal(got, want); diff != nil {
+				t.Fatal("ElmRecord struct did not match expectations:\n" + strings.Join(diff, "\n"))
+			}
+			if !got.Equal(want) {
+				t.Error("ElmRecord struct did not match expectations, likely in an ElmType field.")
+			}
+			if gotWarnings := strings.Count(buf.String(), "64-bit integer"); gotWarnings != tt.wantWarnings {
+				t.Errorf("got %v warnings, want %v:\n%s", gotWarnings, tt.wantWarnings, buf)
+			}
+		})
+	}
+}
+
+func TestRecordFromStructBytes(t *testing.T) {
+	pkgs, err := pkgCache.load(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "ByteSlices"
+	for _, decoded := range []bool{false, true} {
+		bytes := &ElmBytes{decoded: decoded}
+		want := &ElmRecord{
+			name: name,
+			Fields: []*ElmField{
+				{JSONName: "Data", ElmName: "data", ElmType: bytes},
+				{JSONName: "Blob", ElmName: "blob", ElmType: bytes},
+				{JSONName: "Chunks", ElmName: "chunks", ElmType: &ElmList{elem: bytes}},
+				{JSONName: "Numbers", ElmName: "numbers", ElmType: &ElmList{elem: elmUint}},
+			},
+		}
+		structType, err := getStructDef(pkgs, "main", name)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		got, err := recordFromStruct(NewResolver(make(TypeNamePairs), Options{Bytes: decoded}),
+			structType, name)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if diff := deep.Equal(got, want); diff != nil {
+			t.Fatal("ElmRecord struct did not match expectations:\n" + strings.Join(diff, "\n"))
+		}
+		if !got.Equal(want) {
+			t.Error("ElmRecord struct did not match expectations, likely in an ElmType field.")
+		}
+	}
+
+	// Under NilMaybe, byte slices are nullable, inside lists too.
+	structType, err := getStructDef(pkgs, "main", name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	got, err := recordFromStruct(NewResolver(make(TypeNamePairs), Options{Nils: NilMaybe}),
+		structType, name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	wantTypes := []string{"Maybe String", "Maybe String", "Maybe (List (Maybe String))"}
+	for i, want := range wantTypes {
+		if gotType := got.Fields[i].TypeDecl(); gotType != want {
+			t.Errorf("Fields[%v] got type %q, want %q", i, gotType, want)
+		}
+	}
+}
+
+func TestRecordFromStructArrays(t *testing.T) {
+	pkgs, err := pkgCache.load(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "ArrayTypes"
+	for _, tuples := range []bool{false, true} {
+		array := func(elem ElmType, length int64) *ElmArray {
+			return &ElmArray{elem: elem, length: length, tuple: tuples && length <= 3}
+		}
+		want := &ElmRecord{
+			name: name,
+			Fields: []*ElmField{
+				{JSONName: "Point", ElmName: "point", ElmType: array(elmFloat, 3)},
+				{JSONName: "Range", ElmName: "range", ElmType: array(elmInt, 2)},
+				{JSONName: "Digest", ElmName: "digest", ElmType: array(elmUint, 4)},
+				{JSONName: "Pairs", ElmName: "pairs", ElmType: &ElmList{elem: array(elmString, 2)}},
+				{JSONName: "Corner", ElmName: "corner", ElmType: &ElmPointer{elem: array(elmInt, 2)}},
+			},
+		}
+		structType, err := getStructDef(pkgs, "main", name)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		got, err := recordFromStruct(NewResolver(make(TypeNamePairs), Options{Tuples: tuples}),
+			structType, name)
+		if err != nil {
+			t.Fatalf("%+v", err)
+		}
+		if diff := deep.Equal(got, want); diff != nil {
+			t.Fatal("ElmRecord struct did not match expectations:\n" + strings.Join(diff, "\n"))
+		}
+		if !got.Equal(want) {
+			t.Error("ElmRecord struct did not match expectations, likely in an ElmType field.")
+		}
+	}
+}
+
+func TestRecordFromStructNestedTypes(t *testing.T) {
+	pkgs, err := pkgCache.load(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "NestedTypes"
+	structType, err := getStructDef(pkgs, "main", name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	// Nil slices and maps are kept as Maybe, to show the nesting of each level.
+	resolver := NewResolver(make(TypeNamePairs), Options{Nils: NilMaybe})
+	got, err := recordFromStruct(resolver, structType, name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	want := []struct{ typeDecl, decoder string }{
+		{"Maybe (List (Maybe (List Int)))", "(D.nullable (D.list (D.nullable (D.list D.int))))"},
+		{"Maybe (List (Maybe String))", "(D.nullable (D.list (D.nullable D.string)))"},
+		{"Maybe (List String)", "(D.nullable (D.list D.string))"},
+		{"Maybe (List (Maybe (List Float)))", "(D.nullable (D.list (D.nullable (D.list D.float))))"},
+		{"Maybe (Dict String (Maybe Int))", "(D.nullable (D.dict (D.nullable D.int)))"},
+		{"Page (Maybe Int)", "(pageDecoder (D.nullable D.int))"},
+		{"Maybe Bool", "(D.nullable D.bool)"},
+		{"Maybe (List (Maybe (Dict String (Maybe (List Int)))))",
+			"(D.nullable (D.list (D.nullable (D.dict (D.nullable (D.list D.int))))))"},
+	}
+	if len(got.Fields) != len(want) {
+		t.Fatalf("got %v fields, want %v", len(got.Fields), len(want))
+	}
+	for i, w := range want {
+		if gotType := got.Fields[i].TypeDecl(); gotType != w.typeDecl {
+			t.Errorf("Fields[%v] got type %q, want %q", i, gotType, w.typeDecl)
+		}
+		if gotDecoder := got.Fields[i].Decoder("D"); gotDecoder != w.decoder {
+			t.Errorf("Fields[%v] got decoder %q, want %q", i, gotDecoder, w.decoder)
+		}
+	}
+}
+
+func TestRecordFromStructOmitZeros(t *testing.T) {
+	pkgs, err := pkgCache.load(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name      string
+		omitZeros bool
+		want      []string
+	}{
+		{"OptionalValues", false, []string{
+			`optionalField "opt-string" E.string`,
+			`optionalField "OptInt" E.int`,
+			`optionalField "OptBool" E.bool`,
+		}},
+		{"OptionalValues", true, []string{
+			`Maybe.andThen (nonZeroField "" "opt-string" E.string)`,
+			`Maybe.andThen (nonZeroField 0 "OptInt" E.int)`,
+			`Maybe.andThen (nonZeroField False "OptBool" E.bool)`,
+		}},
+		// Nil pointers are omitted, there is no zero value to compare with.
+		{"NullableValues", true, []string{`optionalField "OptNullString" E.string`}},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%s/%v", tc.name, tc.omitZeros), func(t *testing.T) {
+			structType, err := getStructDef(pkgs, "main", tc.name)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			resolver := NewResolver(make(TypeNamePairs), Options{OmitZeros: tc.omitZeros})
+			record, err := recordFromStruct(resolver, structType, tc.name)
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			var got []string
+			for _, f := range record.Fields {
+				if f.Optional {
+					got = append(got, f.OptionalEncoder("E"))
+				}
+			}
+			if diff := deep.Equal(got, tc.want); diff != nil {
+				t.Error("optional encoders did not match expectations:\n" + strings.Join(diff, "\n"))
+			}
+		})
+	}
+}
+
+func TestRecordFromStructZeroDefaults(t *testing.T) {
+	pkgs, err := pkgCache.load(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := "ZeroValues"
+	structType, err := getStructDef(pkgs, "main", name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	resolver := NewResolver(make(TypeNamePairs), Options{ZeroDefaults: true})
+	got, err := recordFromStruct(resolver, structType, name)
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	// Pointers, and types without a zero value, remain Maybe.
+	want := []struct {
+		typeDecl, defaultValue string
+		zeroDefault            bool
+	}{
+		{"Int", " 0", true},
+		{"Float", " 0.0", true},
+		{"String", ` ""`, true},
+		{"Bool", " False", true},
+		{"UserId", ` ""`, true},
+		{"List String", " []", true},
+		{"Dict String Int", " Dict.empty", true},
+		{"Address", ` { street = "", city = "" }`, true},
+		{"Maybe String", " Nothing", false},
+		{"Maybe Time.Posix", " Nothing", false},
+		{"Dict String String", "", false},
+	}
+	if len(got.Fields) != len(want) {
+		t.Fatalf("got %v fields, want %v", len(got.Fields), len(want))
+	}
+	for i, w := range want {
+		if gotType := got.Fields[i].TypeDecl(); gotType != w.typeDecl {
+			t.Errorf("Fields[%v] got type %q, want %q", i, gotType, w.typeDecl)
+		}
+		if gotDefault := got.Fields[i].Default(); gotDefault != w.defaultValue {
+			t.Errorf("Fields[%v] got default %q, want %q", i, gotDefault, w.defaultValue)
+		}
+		if got.Fields[i].zeroDefault != w.zeroDefault {
+			t.Errorf("Fields[%v] got zeroDefault %v, want %v", i, got.Fields[i].zeroDefault,
+				w.zeroDefault)
+		}
+		if (got.Fields[i].zero != nil) != w.zeroDefault {
+			t.Errorf("Fields[%v] got zero %v, want zero %v", i, got.Fields[i].zero, w.zeroDefault)
+		}
+	}
+}
+
+func TestResolveRootAnonymous(t *testing.T) {
+	pkgs, err := pkgCache.load(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		renames []string
+		want    []string
+		wantErr bool
+	}{
+		{want: []string{"ResponseMeta", "ResponseItemsOwner", "ResponseItems"}},
+		{renames: []string{"ResponseMeta:Meta", "ResponseItems:Item"},
+			want: []string{"Meta", "ItemOwner", "Item"}},
+		{renames: []string{"ResponseMeta:Response"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.renames, ","), func(t *testing.T) {
+			renames := make(TypeNamePairs)
+			for _, rename := range tt.renames {
+				renames.Add(rename)
+			}
+			namedType, err := getNamedStruct(pkgs, "main", "Response")
+			if err != nil {
+				t.Fatalf("%+v", err)
+			}
+			resolver := NewResolver(renames, Options{})
+			record, err := resolver.ResolveRoot(namedType)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var got []string
+			for _, record := range resolver.CachedRecords() {
+				got = append(got, record.Name())
+			}
+			if diff := deep.Equal(got, tt.want); diff != nil {
+				t.Error("nested records did not match expectations:\n" + strings.Join(diff, "\n"))
+			}
+			// Identical anonymous structs share a record.
+			if record.Fields[0].ElmType != record.Fields[2].ElmType {
+				t.Errorf("Meta and Totals got types %s and %s, want the same record",
+					elmTypeName(record.Fields[0].ElmType), elmTypeName(record.Fields[2].ElmType))
+			}
+		})
 	}
 }
@@ -1,23 +1,161 @@
 package main
 
 import (
+	"reflect"
 	"strings"
-)
+	"unicode"
 
-const (
-	tagPrefix = "json:\""
-	tagSuffix = "\""
+	"github.com/pkg/errors"
 )
 
-// parseTag splits a struct field's json tag into its name and comma-separated options.
-func parseTag(tag string) (string, string) {
-	if !strings.HasPrefix(tag, tagPrefix) {
-		return "", ""
+// jsonTag returns the value of the json key in a struct field's tag.
+func jsonTag(tag string) string {
+	return reflect.StructTag(tag).Get("json")
+}
+
+// elmTag returns the value of the elm key in a struct field's tag.
+func elmTag(tag string) string {
+	return reflect.StructTag(tag).Get("elm")
+}
+
+// elmOptions holds the overrides of a struct field's elm tag, such as `elm:"name=userId,required"`.
+// They apply after the json tag.
+type elmOptions struct {
+	name     string // Elm field name.
+	skip     bool   // Leave the field out of the Elm record.
+	required bool
+	optional bool
+	typ      string // Elm type, requires decoder and encoder.
+	imp      string // Elm module import required by the type, decoder or encoder.
+	decoder  string
+	encoder  string
+}
+
+// elmKeywords may not be used as Elm field names.
+var elmKeywords = map[string]bool{
+	"as": true, "case": true, "else": true, "exposing": true, "if": true, "import": true,
+	"in": true, "let": true, "module": true, "of": true, "port": true, "then": true, "type": true,
+}
+
+// parseElmTag parses the comma-separated options of an elm tag value.  Commas nested in brackets
+// or string literals belong to the option value, such as `decoder=D.oneOf [ D.int, D.succeed 0 ]`.
+func parseElmTag(s string) (elmOptions, error) {
+	var o elmOptions
+	if s == "" {
+		return o, nil
 	}
-	if !strings.HasSuffix(tag, tagSuffix) {
-		return "", ""
+	opts, err := splitOptions(s)
+	if err != nil {
+		return o, err
 	}
-	tag = tag[len(tagPrefix) : len(tag)-len(tagSuffix)]
+	seen := make(map[string]bool)
+	for _, opt := range opts {
+		key, value, hasValue := strings.Cut(opt, "=")
+		if seen[key] {
+			return o, errors.Errorf("elm tag option %q repeated", key)
+		}
+		seen[key] = true
+		switch key {
+		case "skip", "required", "optional":
+			if hasValue {
+				return o, errors.Errorf("elm tag option %q takes no value", key)
+			}
+		case "name", "type", "import", "decoder", "encoder":
+			if value == "" {
+				return o, errors.Errorf("elm tag option %q requires a value", key)
+			}
+		default:
+			return o, errors.Errorf("unknown elm tag option %q", opt)
+		}
+		switch key {
+		case "name":
+			o.name = value
+		case "skip":
+			o.skip = true
+		case "required":
+			o.required = true
+		case "optional":
+			o.optional = true
+		case "type":
+			o.typ = value
+		case "import":
+			o.imp = value
+		case "decoder":
+			o.decoder = value
+		case "encoder":
+			o.encoder = value
+		}
+	}
+	switch {
+	case o.skip && len(seen) > 1:
+		return o, errors.New("elm tag option skip cannot be combined with other options")
+	case o.required && o.optional:
+		return o, errors.New("elm tag options required and optional cannot be combined")
+	case o.typ != "" && (o.decoder == "" || o.encoder == ""):
+		return o, errors.New("elm tag option type requires decoder and encoder options")
+	case o.name != "" && !isElmFieldName(o.name):
+		return o, errors.Errorf("elm tag name %q is not a valid Elm field name", o.name)
+	}
+	return o, nil
+}
+
+// splitOptions splits an elm tag value at the commas outside of brackets and string literals.
+func splitOptions(s string) ([]string, error) {
+	var opts []string
+	var closers []rune
+	inString, escaped := false, false
+	start := 0
+	for i, c := range s {
+		switch {
+		case inString:
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '(':
+			closers = append(closers, ')')
+		case c == '[':
+			closers = append(closers, ']')
+		case c == '{':
+			closers = append(closers, '}')
+		case c == ')' || c == ']' || c == '}':
+			if len(closers) == 0 || closers[len(closers)-1] != c {
+				return nil, errors.Errorf("elm tag %q has unbalanced %q", s, c)
+			}
+			closers = closers[:len(closers)-1]
+		case c == ',' && len(closers) == 0:
+			opts = append(opts, s[start:i])
+			start = i + 1
+		}
+	}
+	if inString || len(closers) > 0 {
+		return nil, errors.Errorf("elm tag %q has an unterminated string or bracket", s)
+	}
+	return append(opts, s[start:]), nil
+}
+
+// isElmFieldName tests if s is a lowercase Elm identifier, other than a keyword.
+func isElmFieldName(s string) bool {
+	for i, c := range s {
+		switch {
+		case i == 0 && !unicode.IsLower(c):
+			return false
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_':
+			return false
+		}
+	}
+	return s != "" && !elmKeywords[s]
+}
+
+// parseTag splits a struct field's json tag into its name and comma-separated options.
+func parseTag(tag string) (string, string) {
+	tag = jsonTag(tag)
 	if idx := strings.Index(tag, ","); idx != -1 {
 		return tag[:idx], tag[idx+1:]
 	}
@@ -42,3 +180,20 @@ func hasOption(name string, s string) bool {
 	}
 	return false
 }
+
+// isValidTag tests if s is an acceptable JSON name, using the same rules as encoding/json.
+func isValidTag(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", c):
+			// Backslash and quote chars are reserved, but otherwise any punctuation chars are
+			// allowed in a tag name.
+		case !unicode.IsLetter(c) && !unicode.IsDigit(c):
+			return false
+		}
+	}
+	return true
+}
@@ -10,6 +10,9 @@ func TestParseTag(t *testing.T) {
 		{"json:\"bad-suffix", "", ""},
 		{"json:\"name\"", "name", ""},
 		{"json:\"name,option\"", "name", "option"},
+		{"json:\"-\"", "-", ""},
+		{"json:\"-,\"", "-", ""},
+		{"xml:\"other\" json:\"name\"", "name", ""},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.input, func(t *testing.T) {
@@ -24,6 +27,64 @@ func TestParseTag(t *testing.T) {
 	}
 }
 
+func TestParseElmTag(t *testing.T) {
+	testCases := []struct {
+		input   string
+		want    elmOptions
+		wantErr bool
+	}{
+		{input: "", want: elmOptions{}},
+		{input: "name=userId", want: elmOptions{name: "userId"}},
+		{input: "skip", want: elmOptions{skip: true}},
+		{input: "required,name=id", want: elmOptions{required: true, name: "id"}},
+		{input: "optional", want: elmOptions{optional: true}},
+		{
+			input: "type=Uuid.Uuid,import=Uuid,decoder=Uuid.decoder,encoder=Uuid.encode",
+			want: elmOptions{
+				typ: "Uuid.Uuid", imp: "Uuid", decoder: "Uuid.decoder", encoder: "Uuid.encode",
+			},
+		},
+		{input: "decoder=D.map (max 0) D.int", want: elmOptions{decoder: "D.map (max 0) D.int"}},
+		{
+			input: `decoder=D.oneOf [ D.int, D.succeed 0 ],encoder=E.int,required`,
+			want:  elmOptions{decoder: "D.oneOf [ D.int, D.succeed 0 ]", encoder: "E.int", required: true},
+		},
+		{
+			input: `decoder=D.map2 Tuple.pair (D.index 0 D.int) (D.index 1 D.int),name=pair`,
+			want:  elmOptions{decoder: "D.map2 Tuple.pair (D.index 0 D.int) (D.index 1 D.int)", name: "pair"},
+		},
+		{
+			input: `encoder=(\( a, b ) -> E.string ("a,]" ++ a ++ b))`,
+			want:  elmOptions{encoder: `(\( a, b ) -> E.string ("a,]" ++ a ++ b))`},
+		},
+		{input: "decoder=D.oneOf [ D.int, D.null 0", wantErr: true},
+		{input: "decoder=D.map f D.int)", wantErr: true},
+		{input: "skip,name=id", wantErr: true},
+		{input: "required,optional", wantErr: true},
+		{input: "type=Uuid.Uuid,decoder=Uuid.decoder", wantErr: true},
+		{input: "name=UserId", wantErr: true},
+		{input: "name=type", wantErr: true},
+		{input: "name=", wantErr: true},
+		{input: "skip=true", wantErr: true},
+		{input: "name=a,name=b", wantErr: true},
+		{input: "omitempty", wantErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := parseElmTag(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tc.wantErr)
+			}
+			if tc.wantErr {
+				return
+			}
+			if got != tc.want {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
 func TestHasOption(t *testing.T) {
 	testCases := []struct {
 		input  string
@@ -45,3 +106,25 @@ func TestHasOption(t *testing.T) {
 		})
 	}
 }
+
+func TestIsValidTag(t *testing.T) {
+	testCases := []struct {
+		input string
+		want  bool
+	}{
+		{"", false},
+		{"name", true},
+		{"-", true},
+		{"kebab-case.name", true},
+		{"quo\"te", false},
+		{"back\\slash", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			got := isValidTag(tc.input)
+			if got != tc.want {
+				t.Errorf("isValidTag(%q) got %v, want %v", tc.input, got, tc.want)
+			}
+		})
+	}
+}
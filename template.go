@@ -1,74 +1,764 @@
 package main
 
 var elmTemplate = `
-{{- with .Record -}}
-module {{.Name}} exposing ({{.Name}}, decoder, encode)
-
-import Json.Decode as D
-import Json.Decode.Pipeline as P
-import Json.Encode as E
+{{- define "recordType" -}}
+{{if .Recursive -}}
+type {{.Name}}{{.TypeParams}}
+    = {{.Name}} {{.FieldsType}}
 
 
-
--- Generated by https://github.com/jhillyerd/go-to-elm-json
-
-
-type alias {{.Name}} =
-{{- range $index, $el := .Fields }}
-    {{ if $index }},{{ else }}{{"{"}}{{ end }} {{ .ElmName }} : {{ .TypeDecl -}}
-{{end}}
-    {{"}"}}
+type alias {{.FieldsName}}{{.TypeParams}} =
+{{- else -}}
+type alias {{.Name}}{{.TypeParams}} =
 {{- end}}
-{{- range .Nested}}
-
-
-type alias {{.Name}} =
 {{- range $index, $el := .Fields }}
     {{ if $index }},{{ else }}{{"{"}}{{ end }} {{ .ElmName }} : {{ .TypeDecl -}}
 {{end}}
     {{"}"}}
-{{- end}}
+{{- end -}}
 
-
-{{with .Record -}}
-decoder : D.Decoder {{.Name}}
-decoder =
-    D.succeed {{.Name}}
+{{- define "recordCodecs" -}}
+{{.Decoder "D" }} : {{.DecoderType}}
+{{.Decoder "D" }}{{.DecoderParams}} =
+    D.succeed {{if .Recursive}}{{.FieldsName}}{{else}}{{.Name}}{{end}}
 {{- range .Fields }}
         |> {{ .Pipeline "P" }} "{{ .JSONName }}" {{ .Decoder "D" }}{{ .Default -}}
 {{end}}
+{{- if .Recursive}}
+        |> D.map {{.Name}}
+{{- end}}
 
 
-encode : {{.Name}} -> E.Value
-encode r =
+{{.Encoder "E" }} : {{.EncoderType}}
+{{.Encoder "E" }}{{.EncoderParams}} {{if .Recursive}}({{.Name}} r){{else}}r{{end}} =
+{{- if .HasOmittable}}
+    E.object <|
+        List.filterMap identity
+{{- range $index, $el := .Fields }}
+            {{ if $index }},{{ else }}[{{ end }}
+{{- if .Omittable }} {{ .OptionalEncoder "E" }} r.{{ .ElmName }}
+{{- else }} Just ( "{{ .JSONName }}", {{ .Encoder "E" }} r.{{ .ElmName }} )
+{{- end}}
+{{- end}}
+            ]
+{{- else}}
     E.object
 {{- range $index, $el := .Fields }}
         {{ if $index }},{{ else }}[{{ end }} ( "{{ .JSONName }}", {{ .Encoder "E" }} r.{{ .ElmName }} )
 {{- end}}
         ]
 {{- end}}
+{{- end -}}
+
+{{- with .Record -}}
+module {{.Name}} exposing ({{.Name}}{{if .Recursive}}(..), {{.FieldsName}}{{end}}, decoder, encode
+{{- range $.Enums}}, {{.Name}}(..), {{.ToJSON}}, {{.FromJSON}}, {{.All}}
+{{- if .Label}}, {{.LabelFunc}}{{end}}{{end}}
+{{- range $.Unions}}, {{.Name}}(..){{end}}
+{{- range $.Nested}}{{if .Recursive}}, {{.Name}}(..), {{.FieldsName}}{{end}}{{end}}
+{{- range $.Wrappers}}, {{.Name}}{{if .Opaque}}, {{.Wrap}}, {{.Unwrap}}{{end}}{{end}})
+{{- end}}
+{{range .Imports}}
+import {{.}}
+{{- end}}
+
+
+
+-- Generated by https://github.com/jhillyerd/go-to-elm-json
+{{- if .Notes}}
+--
+{{- range .Notes}}
+-- {{.}}
+{{- end}}
+{{- end}}
+
+
+{{with .Record -}}
+{{template "recordType" .}}
+{{- end}}
+{{- range .Nested}}
+
+
+{{template "recordType" .}}
+{{- end}}
+{{- range .Enums}}
+
+
+type {{.Name}}
+{{- range $index, $el := .Values }}
+    {{ if $index }}|{{ else }}={{ end }} {{ .Constructor }}
+{{- end}}
+{{- end}}
+{{- range .Unions}}
+
+
+type {{.Name}}
+{{- range $index, $el := .Variants }}
+    {{ if $index }}|{{ else }}={{ end }} {{ .Constructor }} {{ .PayloadType }}
+{{- end}}
+{{- end}}
+{{- range .Wrappers}}
+
+
+{{if .Opaque -}}
+type {{.Name}}
+    = {{.Name}} {{.Basic.Name}}
+{{- else -}}
+type alias {{.Name}} =
+    {{.Basic.Name}}
+{{- end}}
+{{- end}}
+
+
+{{with .Record -}}
+{{template "recordCodecs" .}}
+{{- end}}
 {{- range .Nested}}
 
 
+{{template "recordCodecs" .}}
+{{- end}}
+{{- range .Enums}}
+
+
 {{.Decoder "D" }} : D.Decoder {{.Name}}
 {{.Decoder "D" }} =
-    D.succeed {{.Name}}
-{{- range .Fields }}
-        |> P.required "{{ .JSONName }}" {{ .Decoder "D" -}}
-{{end}}
+    {{.Basic.Decoder "D"}}
+        |> D.andThen
+            (\v ->
+                case {{.FromJSON}} v of
+                    Just value ->
+                        D.succeed value
+
+                    Nothing ->
+                        D.fail ("Unknown {{.Name}}: " ++ {{if .IsString}}v{{else}}String.fromInt v{{end}})
+            )
 
 
 {{.Encoder "E" }} : {{.Name}} -> E.Value
-{{.Encoder "E" }} r =
-    E.object
-{{- range $index, $el := .Fields }}
-        {{ if $index }},{{ else }}[{{ end }} ( "{{ .JSONName }}", {{ .Encoder "E" }} r.{{ .ElmName }} )
+{{.Encoder "E" }} =
+    {{.ToJSON}} >> {{.Basic.Encoder "E"}}
+
+
+{{.ToJSON}} : {{.Name}} -> {{.Basic.Name}}
+{{.ToJSON}} v =
+    case v of
+{{- range $index, $el := .Values }}
+{{- if $index }}
+{{ end }}
+        {{ .Constructor }} ->
+            {{ .Literal }}
+{{- end}}
+
+
+{{.FromJSON}} : {{.Basic.Name}} -> Maybe {{.Name}}
+{{.FromJSON}} v =
+{{- if .IsString }}
+    case v of
+{{- range .Values }}
+        {{ .Literal }} ->
+            Just {{ .Constructor }}
+{{ end }}
+        _ ->
+            Nothing
+{{- else }}
+{{- range $index, $el := .Values }}
+    {{ if $index }}else {{ end }}if v == {{ .Literal }} then
+        Just {{ .Constructor }}
+{{ end }}
+    else
+        Nothing
+{{- end }}
+
+
+{{.All}} : List {{.Name}}
+{{.All}} =
+{{- range $index, $el := .Values }}
+    {{ if $index }},{{ else }}[{{ end }} {{ .Constructor }}
+{{- end}}
+    ]
+{{- if .Label}}
+
+
+{{.LabelFunc}} : {{.Name}} -> String
+{{.LabelFunc}} v =
+    case v of
+{{- range $index, $el := .Values }}
+{{- if $index }}
+{{ end }}
+        {{ .Constructor }} ->
+            "{{ .GoName }}"
+{{- end}}
+{{- end}}
+{{- end}}
+{{- range .Unions}}
+{{- $union := .}}
+
+
+{{.Decoder "D" }} : D.Decoder {{.Name}}
+{{.Decoder "D" }} =
+    D.field {{.DiscriminatorLiteral}} D.string
+        |> D.andThen
+            (\tag ->
+                case tag of
+{{- range .Variants }}
+                    {{ .Tag }} ->
+                        D.map {{ .Constructor }} {{ .PayloadDecoder "D" }}
+{{ end }}
+                    _ ->
+                        D.fail ("Unknown {{.Name}} {{.Discriminator}}: " ++ tag)
+            )
+
+
+{{.Encoder "E" }} : {{.Name}} -> E.Value
+{{.Encoder "E" }} v =
+    case v of
+{{- range $index, $el := .Variants }}
+{{- if $index }}
+{{ end }}
+        {{ .Constructor }} r ->
+            taggedEncoder {{ $union.DiscriminatorLiteral }} {{ .Tag }} ({{ .PayloadEncoder "E" }} r)
+{{- end}}
+{{- end}}
+{{- range .Wrappers}}
+{{- if .Opaque}}
+
+
+{{.Decoder "D" }} : D.Decoder {{.Name}}
+{{.Decoder "D" }} =
+    D.map {{.Name}} {{.Basic.Decoder "D"}}
+
+
+{{.Encoder "E" }} : {{.Name}} -> E.Value
+{{.Encoder "E" }} ({{.Name}} v) =
+    {{.Basic.Encoder "E"}} v
+
+
+{{.Wrap}} : {{.Basic.Name}} -> {{.Name}}
+{{.Wrap}} =
+    {{.Name}}
+
+
+{{.Unwrap}} : {{.Name}} -> {{.Basic.Name}}
+{{.Unwrap}} ({{.Name}} v) =
+    v
 {{- end}}
-        ]
 {{- end}}
 
 
 maybe : (a -> E.Value) -> Maybe a -> E.Value
 maybe encoder =
     Maybe.map encoder >> Maybe.withDefault E.null
+{{- range .Helpers}}
+
+
+{{.}}
+{{- end}}
 `
+
+// elmHelpers contains Elm support functions, included in the output only when required.
+var elmHelpers = map[string]string{
+	"base64": `decodeBase64 : D.Decoder Bytes
+decodeBase64 =
+    D.string
+        |> D.andThen
+            (\s ->
+                case base64ToBytes s of
+                    Just bytes ->
+                        D.succeed bytes
+
+                    Nothing ->
+                        D.fail ("Invalid base64: " ++ s)
+            )
+
+
+base64Encoder : Bytes -> E.Value
+base64Encoder =
+    bytesToBase64 >> E.string
+
+
+base64Alphabet : String
+base64Alphabet =
+    "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+
+base64ToBytes : String -> Maybe Bytes
+base64ToBytes s =
+    let
+        body =
+            if String.endsWith "==" s then
+                String.dropRight 2 s
+
+            else if String.endsWith "=" s then
+                String.dropRight 1 s
+
+            else
+                s
+
+        sextet c =
+            let
+                code =
+                    Char.toCode c
+            in
+            if Char.isUpper c then
+                Just (code - 65)
+
+            else if Char.isLower c then
+                Just (code - 71)
+
+            else if Char.isDigit c then
+                Just (code + 4)
+
+            else if c == '+' then
+                Just 62
+
+            else if c == '/' then
+                Just 63
+
+            else
+                Nothing
+
+        toOctets values acc =
+            case values of
+                a :: b :: c :: d :: rest ->
+                    let
+                        n =
+                            a * 262144 + b * 4096 + c * 64 + d
+                    in
+                    toOctets rest (modBy 256 n :: modBy 256 (n // 256) :: n // 65536 :: acc)
+
+                [ a, b, c ] ->
+                    let
+                        n =
+                            a * 4096 + b * 64 + c
+                    in
+                    List.reverse (modBy 256 (n // 4) :: n // 1024 :: acc)
+
+                [ a, b ] ->
+                    List.reverse ((a * 64 + b) // 16 :: acc)
+
+                _ ->
+                    List.reverse acc
+    in
+    if modBy 4 (String.length s) /= 0 then
+        Nothing
+
+    else
+        String.toList body
+            |> List.foldr (\c acc -> Maybe.map2 (::) (sextet c) acc) (Just [])
+            |> Maybe.map
+                (\values ->
+                    toOctets values []
+                        |> List.map Bytes.Encode.unsignedInt8
+                        |> Bytes.Encode.sequence
+                        |> Bytes.Encode.encode
+                )
+
+
+bytesToBase64 : Bytes -> String
+bytesToBase64 bytes =
+    let
+        octetStep ( remaining, acc ) =
+            if remaining <= 0 then
+                Bytes.Decode.succeed (Bytes.Decode.Done (List.reverse acc))
+
+            else
+                Bytes.Decode.map
+                    (\o -> Bytes.Decode.Loop ( remaining - 1, o :: acc ))
+                    Bytes.Decode.unsignedInt8
+
+        octets =
+            Bytes.Decode.decode (Bytes.Decode.loop ( Bytes.width bytes, [] ) octetStep) bytes
+                |> Maybe.withDefault []
+
+        digits count n =
+            List.range 1 count
+                |> List.map
+                    (\i ->
+                        let
+                            index =
+                                modBy 64 (n // (2 ^ (6 * (count - i))))
+                        in
+                        String.slice index (index + 1) base64Alphabet
+                    )
+                |> String.concat
+
+        quads values acc =
+            case values of
+                a :: b :: c :: rest ->
+                    quads rest (digits 4 (a * 65536 + b * 256 + c) :: acc)
+
+                [ a, b ] ->
+                    List.reverse ((digits 3 ((a * 256 + b) * 4) ++ "=") :: acc)
+
+                [ a ] ->
+                    List.reverse ((digits 2 (a * 16) ++ "==") :: acc)
+
+                [] ->
+                    List.reverse acc
+    in
+    String.concat (quads octets [])`,
+
+	"fixedLength": `fixedLength : Int -> D.Decoder a -> D.Decoder a
+fixedLength length valueDecoder =
+    D.list D.value
+        |> D.andThen
+            (\values ->
+                if List.length values == length then
+                    valueDecoder
+
+                else
+                    D.fail
+                        ("Expected array of length "
+                            ++ String.fromInt length
+                            ++ ", got "
+                            ++ String.fromInt (List.length values)
+                        )
+            )`,
+
+	"keyedPairs": `decodeKeyedPairs : (String -> Maybe k) -> D.Decoder v -> D.Decoder (List ( k, v ))
+decodeKeyedPairs parseKey valueDecoder =
+    let
+        parsePair ( key, value ) result =
+            case ( parseKey key, result ) of
+                ( Just k, Ok pairs ) ->
+                    Ok (( k, value ) :: pairs)
+
+                ( Nothing, _ ) ->
+                    Err ("Invalid map key: " ++ key)
+
+                ( _, Err err ) ->
+                    Err err
+    in
+    D.keyValuePairs valueDecoder
+        |> D.andThen
+            (\pairs ->
+                case List.foldr parsePair (Ok []) pairs of
+                    Ok parsed ->
+                        D.succeed parsed
+
+                    Err err ->
+                        D.fail err
+            )
+
+
+keyedPairsEncoder : (k -> String) -> (v -> E.Value) -> List ( k, v ) -> E.Value
+keyedPairsEncoder formatKey encoder =
+    E.object << List.map (\( k, v ) -> ( formatKey k, encoder v ))`,
+
+	"stringified": `stringified : D.Decoder a -> D.Decoder a
+stringified valueDecoder =
+    D.string
+        |> D.andThen
+            (\s ->
+                case D.decodeString valueDecoder s of
+                    Ok value ->
+                        D.succeed value
+
+                    Err err ->
+                        D.fail ("Invalid stringified value " ++ s ++ ": " ++ D.errorToString err)
+            )
+
+
+stringifiedEncoder : (a -> E.Value) -> a -> E.Value
+stringifiedEncoder encoder =
+    encoder >> E.encode 0 >> E.string`,
+
+	"tuples": `decodePair : D.Decoder a -> D.Decoder ( a, a )
+decodePair valueDecoder =
+    fixedLength 2 (D.map2 Tuple.pair (D.index 0 valueDecoder) (D.index 1 valueDecoder))
+
+
+decodeTriple : D.Decoder a -> D.Decoder ( a, a, a )
+decodeTriple valueDecoder =
+    fixedLength 3
+        (D.map3 (\a b c -> ( a, b, c ))
+            (D.index 0 valueDecoder)
+            (D.index 1 valueDecoder)
+            (D.index 2 valueDecoder)
+        )
+
+
+pairEncoder : (a -> E.Value) -> ( a, a ) -> E.Value
+pairEncoder encoder ( a, b ) =
+    E.list encoder [ a, b ]
+
+
+tripleEncoder : (a -> E.Value) -> ( a, a, a ) -> E.Value
+tripleEncoder encoder ( a, b, c ) =
+    E.list encoder [ a, b, c ]`,
+
+	"uint": `decodeUint : D.Decoder Int
+decodeUint =
+    D.int
+        |> D.andThen
+            (\v ->
+                if v < 0 then
+                    D.fail ("Invalid unsigned integer: " ++ String.fromInt v)
+
+                else
+                    D.succeed v
+            )
+
+
+parseUint : String -> Maybe Int
+parseUint s =
+    String.toInt s
+        |> Maybe.andThen
+            (\v ->
+                if v < 0 then
+                    Nothing
+
+                else
+                    Just v
+            )`,
+
+	"tagged": `taggedEncoder : String -> String -> E.Value -> E.Value
+taggedEncoder field tag value =
+    case D.decodeValue (D.keyValuePairs D.value) value of
+        Ok pairs ->
+            E.object (( field, E.string tag ) :: List.filter (\( k, _ ) -> k /= field) pairs)
+
+        Err _ ->
+            value`,
+
+	"nullIfEmpty": `nullIfEmpty : (a -> Bool) -> (a -> E.Value) -> a -> E.Value
+nullIfEmpty isEmpty encoder value =
+    if isEmpty value then
+        E.null
+
+    else
+        encoder value`,
+
+	"nonZeroField": `nonZeroField : a -> String -> (a -> E.Value) -> a -> Maybe ( String, E.Value )
+nonZeroField zero name encoder value =
+    if value == zero then
+        Nothing
+
+    else
+        Just ( name, encoder value )`,
+
+	"optionalField": `optionalField : String -> (a -> E.Value) -> Maybe a -> Maybe ( String, E.Value )
+optionalField name encoder value =
+    Maybe.map (\v -> ( name, encoder v )) value`,
+
+	"posix": `decodePosix : D.Decoder Time.Posix
+decodePosix =
+    D.string
+        |> D.andThen
+            (\s ->
+                case rfc3339ToPosix s of
+                    Just time ->
+                        D.succeed time
+
+                    Nothing ->
+                        D.fail ("Invalid RFC 3339 time: " ++ s)
+            )
+
+
+posixEncoder : Time.Posix -> E.Value
+posixEncoder =
+    posixToRfc3339 >> E.string
+
+
+rfc3339ToPosix : String -> Maybe Time.Posix
+rfc3339ToPosix s =
+    let
+        digits start end str =
+            let
+                part =
+                    String.slice start end str
+            in
+            if String.length part == end - start && String.all Char.isDigit part then
+                String.toInt part
+
+            else
+                Nothing
+
+        charAt index str =
+            String.slice index (index + 1) str
+
+        ( body, offset ) =
+            if String.endsWith "Z" s || String.endsWith "z" s then
+                ( String.dropRight 1 s, Just 0 )
+
+            else
+                let
+                    zone =
+                        String.right 6 s
+
+                    sign =
+                        case charAt 0 zone of
+                            "+" ->
+                                Just 1
+
+                            "-" ->
+                                Just -1
+
+                            _ ->
+                                Nothing
+                in
+                if charAt 3 zone == ":" then
+                    ( String.dropRight 6 s
+                    , Maybe.map3 (\sg h m -> sg * (h * 60 + m)) sign (digits 1 3 zone) (digits 4 6 zone)
+                    )
+
+                else
+                    ( s, Nothing )
+
+        fraction =
+            String.dropLeft 19 body
+
+        millis =
+            if fraction == "" then
+                Just 0
+
+            else if charAt 0 fraction == "." then
+                digits 1 (String.length fraction) fraction
+                    |> Maybe.andThen (\_ -> String.toInt (String.left 3 (String.dropLeft 1 fraction ++ "00")))
+
+            else
+                Nothing
+
+        date =
+            Maybe.map3 (\y mo d -> ( y, mo, d )) (digits 0 4 body) (digits 5 7 body) (digits 8 10 body)
+
+        clock =
+            Maybe.map3 (\h mi sec -> ( h, mi, sec )) (digits 11 13 body) (digits 14 16 body) (digits 17 19 body)
+
+        separated =
+            (charAt 4 body == "-")
+                && (charAt 7 body == "-")
+                && List.member (charAt 10 body) [ "T", "t" ]
+                && (charAt 13 body == ":")
+                && (charAt 16 body == ":")
+
+        daysInMonth year month =
+            if month == 2 then
+                if (modBy 4 year == 0 && modBy 100 year /= 0) || modBy 400 year == 0 then
+                    29
+
+                else
+                    28
+
+            else if List.member month [ 4, 6, 9, 11 ] then
+                30
+
+            else
+                31
+
+        daysFromCivil year month day =
+            let
+                y =
+                    if month <= 2 then
+                        year - 1
+
+                    else
+                        year
+
+                era =
+                    floor (toFloat y / 400)
+
+                yearOfEra =
+                    y - era * 400
+
+                dayOfYear =
+                    (153 * modBy 12 (month + 9) + 2) // 5 + day - 1
+            in
+            era * 146097 + yearOfEra * 365 + yearOfEra // 4 - yearOfEra // 100 + dayOfYear - 719468
+    in
+    case ( ( date, clock ), ( millis, offset ) ) of
+        ( ( Just ( year, month, day ), Just ( hour, minute, second ) ), ( Just ms, Just minutesEast ) ) ->
+            if
+                separated
+                    && (month >= 1 && month <= 12)
+                    && (day >= 1 && day <= daysInMonth year month)
+                    && (hour <= 23 && minute <= 59 && second <= 59)
+            then
+                let
+                    minutes =
+                        (daysFromCivil year month day * 24 + hour) * 60 + minute - minutesEast
+                in
+                Just (Time.millisToPosix ((minutes * 60 + second) * 1000 + ms))
+
+            else
+                Nothing
+
+        _ ->
+            Nothing
+
+
+posixToRfc3339 : Time.Posix -> String
+posixToRfc3339 time =
+    let
+        pad width value =
+            String.padLeft width '0' (String.fromInt value)
+
+        month =
+            case Time.toMonth Time.utc time of
+                Time.Jan ->
+                    1
+
+                Time.Feb ->
+                    2
+
+                Time.Mar ->
+                    3
+
+                Time.Apr ->
+                    4
+
+                Time.May ->
+                    5
+
+                Time.Jun ->
+                    6
+
+                Time.Jul ->
+                    7
+
+                Time.Aug ->
+                    8
+
+                Time.Sep ->
+                    9
+
+                Time.Oct ->
+                    10
+
+                Time.Nov ->
+                    11
+
+                Time.Dec ->
+                    12
+
+        millis =
+            Time.toMillis Time.utc time
+
+        fraction =
+            if millis == 0 then
+                ""
+
+            else if modBy 100 millis == 0 then
+                "." ++ String.fromInt (millis // 100)
+
+            else if modBy 10 millis == 0 then
+                "." ++ pad 2 (millis // 10)
+
+            else
+                "." ++ pad 3 millis
+    in
+    pad 4 (Time.toYear Time.utc time)
+        ++ "-"
+        ++ pad 2 month
+        ++ "-"
+        ++ pad 2 (Time.toDay Time.utc time)
+        ++ "T"
+        ++ pad 2 (Time.toHour Time.utc time)
+        ++ ":"
+        ++ pad 2 (Time.toMinute Time.utc time)
+        ++ ":"
+        ++ pad 2 (Time.toSecond Time.utc time)
+        ++ fraction
+        ++ "Z"`,
+}
@@ -0,0 +1,14 @@
+// Package billing declares types sharing names with the examples.
+package billing
+
+// Address is a billing address.
+type Address struct {
+	Account string
+	Street  string
+}
+
+// Amount is a monetary amount.
+type Amount struct {
+	Cents    int
+	Currency string
+}
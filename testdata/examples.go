@@ -1,6 +1,12 @@
 package main
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jhillyerd/go-to-elm-json/testdata/billing"
+)
 
 // AnInterface is a boring interface.
 type AnInterface interface {
@@ -67,6 +73,509 @@ type NullableValues struct {
 	NullStruct    *innerStruct
 }
 
+// MapTypes defines some string-keyed maps.
+type MapTypes struct {
+	Counts  map[string]int
+	Lists   map[string][]string
+	Structs map[string]innerStruct
+}
+
+// KeyedMaps defines maps with non-string keys.
+type KeyedMaps struct {
+	ByInt   map[int]string
+	ByUint  map[uint64]bool
+	ByText  map[textKey]int
+	ByNamed map[namedKey]float64
+}
+
+type namedKey string
+
+// textKey is marshaled as text when used as a map key.
+type textKey struct {
+	A, B string
+}
+
+func (k textKey) MarshalText() ([]byte, error) {
+	return []byte(k.A + "/" + k.B), nil
+}
+
+// SkippedFields follows the encoding/json field visibility rules.
+type SkippedFields struct {
+	Kept     string
+	Skipped  string   `json:"-"`
+	Dash     string   `json:"-,"`
+	Invalid  string   `json:"it's"`
+	Channel  chan int `json:"-"`
+	Callback func()   `json:"-"`
+}
+
+// ChannelField cannot be marshaled.
+type ChannelField struct {
+	Events chan string
+}
+
+// FuncField cannot be marshaled.
+type FuncField struct {
+	Callback *func()
+}
+
+// BaseModel is embedded by other structs.
+type BaseModel struct {
+	ID      int    `json:"id"`
+	Created string `json:"created"`
+}
+
+// Owner is embedded by pointer.
+type Owner struct {
+	Name  string
+	Email string `json:"Email"`
+	Color string
+}
+
+// timestamps is an unexported embedded struct with exported fields.
+type timestamps struct {
+	Updated string
+	Email   string
+	Color   string
+}
+
+// Labels is an embedded non-struct type.
+type Labels []string
+
+// EmbeddedStructs promotes the fields of embedded structs.
+type EmbeddedStructs struct {
+	BaseModel
+	*Owner
+	timestamps
+	Labels
+	innerStruct `json:"inner"`
+	Name        string
+}
+
+// PromotedIDs has an ID field beside the one promoted from BaseModel, under a distinct JSON key.
+type PromotedIDs struct {
+	BaseModel
+	ID string `json:"user_id"`
+}
+
+// PromotedClash renames a field to the Elm name of a promoted field.
+type PromotedClash struct {
+	BaseModel
+	Key string `elm:"name=id"`
+}
+
+// Pair has the name of a tuple helper.
+type Pair struct {
+	Left  string
+	Right string
+}
+
+// HelperNames holds types named like helper functions.
+type HelperNames struct {
+	Pair   Pair
+	Coords [2]int
+}
+
+// Kind has a constructor named like the Address record.
+type Kind string
+
+// Kind values.
+const (
+	KindAddress Kind = "address"
+	KindOther   Kind = "other"
+)
+
+// Shipment declares its Kind before the Address record.
+type Shipment struct {
+	Kind    Kind
+	Address Address
+}
+
+// Parcel declares its Address record before the Kind.
+type Parcel struct {
+	Address Address
+	Kind    Kind
+}
+
+// Status is a string enum.
+type Status string
+
+// Status values.
+const (
+	StatusActive   Status = "active"
+	StatusInactive Status = "inactive"
+	StatusArchived Status = "archived"
+	StatusDefault         = StatusActive
+)
+
+// Role has constructor names clashing with Status.
+type Role string
+
+// Role values.
+const (
+	RoleActive Role = "active-role"
+	RoleAdmin  Role = "admin"
+)
+
+// StringEnums uses string enum types.
+type StringEnums struct {
+	Status   Status
+	Role     *Role
+	History  []Status
+	ByStatus map[Status]int
+}
+
+// Priority is an integer enum with a String method.
+type Priority int
+
+// Priority values.
+const (
+	PriorityLow Priority = iota
+	PriorityMedium
+	PriorityHigh
+	_
+	PriorityUrgent
+	PriorityNone Priority = -1
+)
+
+func (p Priority) String() string {
+	return fmt.Sprintf("Priority(%d)", int(p))
+}
+
+// Level is an integer enum without a String method.
+type Level uint8
+
+// Level values.
+const (
+	LevelDebug Level = iota + 1
+	LevelInfo
+)
+
+// IntEnums uses integer enum types.
+type IntEnums struct {
+	Priority   Priority
+	Level      *Level
+	ByPriority map[Priority]string
+}
+
+// UserID is a named string.
+type UserID string
+
+// Cents is a named integer.  A single constant does not make it an enum.
+type Cents int64
+
+// ZeroCents is the zero amount.
+const ZeroCents Cents = 0
+
+// Ratio is a named float.
+type Ratio float64
+
+// NamedBasics uses named basic types.
+type NamedBasics struct {
+	ID      UserID
+	Friends []UserID
+	Balance *Cents
+	Ratio   Ratio
+	ByUser  map[UserID]Cents
+}
+
+// Currency is a string type with constants, which may be represented as a wrapper.
+type Currency string
+
+// Currency values.
+const (
+	CurrencyUSD Currency = "USD"
+	CurrencyEUR Currency = "EUR"
+)
+
+// Prices uses types with constants.
+type Prices struct {
+	Amount   Cents
+	Currency Currency
+}
+
+// TimeTypes uses time.Time.
+type TimeTypes struct {
+	Created time.Time
+	Updated *time.Time
+	History []time.Time
+	ByName  map[string]time.Time
+}
+
+// Thread contains a self-referential struct.
+type Thread struct {
+	Title    string
+	Comments []Comment
+}
+
+// Comment refers to itself.
+type Comment struct {
+	Text    string
+	Replies []Comment
+	Parent  *Comment `json:",omitempty"`
+}
+
+// Category and Product are mutually recursive.
+type Category struct {
+	Name     string
+	Products []Product
+	Parent   *Category
+}
+
+// Product refers back to Category.
+type Product struct {
+	Name     string
+	Category Category
+}
+
+// Address shares its name with billing.Address.
+type Address struct {
+	Street string
+	City   string
+}
+
+// Addresses refers to same-named structs from different packages.
+type Addresses struct {
+	Shipping Address
+	Billing  billing.Address
+}
+
+// Page is a generic struct.
+type Page[T any] struct {
+	Items []T
+	Total int
+}
+
+// Envelope has two type parameters.
+type Envelope[T any, M any] struct {
+	Data T
+	Meta M
+}
+
+// Tree is a recursive generic struct.
+type Tree[T any] struct {
+	Value    T
+	Children []Tree[T]
+}
+
+// Cursor holds an anonymous struct using its type parameters.
+type Cursor[T any, M any] struct {
+	Items  []T
+	Bounds struct {
+		First, Last T
+		Count       int
+	}
+	Meta M
+}
+
+// GenericAnonymous instantiates a generic struct holding an anonymous struct.
+type GenericAnonymous struct {
+	Ints  Cursor[int, string]
+	Names Cursor[string, bool]
+}
+
+// GenericTypes instantiates generic structs.
+type GenericTypes struct {
+	Names    Page[string]
+	Counts   Page[int]
+	Wrapped  Envelope[Page[Address], map[string]int]
+	Branches Tree[float64]
+}
+
+// Event is a sealed interface, generated as a tagged union.
+type Event interface {
+	isEvent()
+}
+
+// Clicked is an Event.
+type Clicked struct {
+	Type string `json:"type"`
+	X, Y int
+}
+
+func (Clicked) isEvent() {}
+
+// Viewed is an Event.
+type Viewed struct {
+	Type string `json:"type"`
+	Page string
+}
+
+func (*Viewed) isEvent() {}
+
+// ClickLog refers to a union member before the union.
+type ClickLog struct {
+	First  Clicked
+	Events []Event
+}
+
+// Keywords has fields named like Elm keywords.
+type Keywords struct {
+	Type   string
+	Module string `json:"module"`
+	Port   int
+}
+
+// EventLog contains Events.
+type EventLog struct {
+	Latest Event
+	Events []Event
+}
+
+// ValueTypes contains untyped values.
+type ValueTypes struct {
+	Raw  json.RawMessage
+	Any  interface{}
+	Meta map[string]any
+	List []any
+}
+
+// Stringified uses the ,string option.
+type Stringified struct {
+	ID      int64   `json:",string"`
+	Ratio   float64 `json:",string"`
+	Enabled bool    `json:",string"`
+	Name    string  `json:",string"`
+	Parent  *int64  `json:",string"`
+	Level   Level   `json:",string"`
+	Tags    []int   `json:",string"` // Ignored by encoding/json.
+}
+
+// BigInts contains 64-bit and unsigned integers.
+type BigInts struct {
+	ID     int64   `json:",string"`
+	Parent *uint64 `json:",string"`
+	Count  int64
+	Counts []uint64
+	Small  uint8
+}
+
+// Blob is a named byte slice.
+type Blob []byte
+
+// ByteSlices contains byte slices.
+type ByteSlices struct {
+	Data    []byte
+	Blob    Blob
+	Chunks  [][]byte
+	Numbers []uint16
+}
+
+// ArrayTypes contains fixed-size arrays.
+type ArrayTypes struct {
+	Point  [3]float64
+	Range  [2]int
+	Digest [4]byte
+	Pairs  [][2]string
+	Corner *[2]int
+}
+
+// Vector is a named fixed-size array.
+type Vector [2]float64
+
+// NamedArrays contains named fixed-size arrays.
+type NamedArrays struct {
+	Origin Vector
+	Path   []Vector
+}
+
+// NestedTypes nests lists, maps and pointers within each other.
+type NestedTypes struct {
+	Matrix  [][]int
+	Names   []*string
+	Tags    *[]string
+	Grid    []*[]float64
+	Scores  map[string]*int
+	Counts  Page[*int]
+	Twice   **bool
+	Lookups []map[string][]int
+}
+
+// ZeroValues has omitempty fields, which are absent from JSON when they hold zero values.
+type ZeroValues struct {
+	Count    int               `json:",omitempty"`
+	Ratio    float64           `json:",omitempty"`
+	Label    string            `json:",omitempty"`
+	Enabled  bool              `json:",omitempty"`
+	Owner    UserID            `json:",omitempty"`
+	Tags     []string          `json:",omitempty"`
+	Limits   map[string]int    `json:",omitempty"`
+	Shipping Address           `json:",omitempty"`
+	Note     *string           `json:",omitempty"`
+	Updated  time.Time         `json:",omitempty"`
+	Required map[string]string `json:"required"`
+}
+
+// Order has omitempty fields in nested structs.
+type Order struct {
+	ID       string
+	Customer Customer
+	Lines    []OrderLine `json:",omitempty"`
+}
+
+// Customer is nested in Order.
+type Customer struct {
+	Name  string
+	Email string  `json:",omitempty"`
+	Phone *string `json:",omitempty"`
+}
+
+// OrderLine is nested in Order.
+type OrderLine struct {
+	Item     string
+	Quantity int    `json:",omitempty"`
+	Note     string `json:"note,omitempty"`
+}
+
+// Response has fields of anonymous struct types.
+type Response struct {
+	Meta struct {
+		Page  int
+		Total int
+	} `json:"meta"`
+	Items []struct {
+		ID    string
+		Owner struct {
+			Name string
+		}
+	}
+	Totals struct {
+		Page  int
+		Total int
+	}
+}
+
+// Invoice has fields of types given custom mappings.
+type Invoice struct {
+	Total  billing.Amount
+	Lines  []billing.Amount
+	ByCode map[string]billing.Amount
+	Tax    *billing.Amount
+	Issued time.Time
+}
+
+// Overrides has fields adjusted by elm tags.
+type Overrides struct {
+	ID       string         `json:"id" elm:"name=userId"`
+	Internal string         `elm:"skip"`
+	Nickname string         `json:",omitempty" elm:"required"`
+	Email    string         `elm:"optional"`
+	Balance  billing.Amount `elm:"type=Money.Amount,import=Money,decoder=Money.decoder,encoder=Money.encode"`
+	Bio      string         `elm:"import=String.Extra,decoder=D.map String.Extra.clean D.string"`
+	Visits   int            `elm:"decoder=D.oneOf [ D.int, D.succeed 0 ]"`
+}
+
+// ElmTagConflict has a field both required and optional.
+type ElmTagConflict struct {
+	Name string `elm:"required,optional"`
+}
+
+// ElmNameClash has two fields with the same Elm name.
+type ElmNameClash struct {
+	Name  string
+	Alias string `elm:"name=name"`
+}
+
 type innerStruct struct {
 	Value string
 }
@@ -2,6 +2,9 @@ package main
 
 import (
 	"go/types"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -9,30 +12,50 @@ import (
 var (
 	elmBool   = &ElmBasicType{name: "Bool", codec: "bool"}
 	elmFloat  = &ElmBasicType{name: "Float", codec: "float"}
-	elmInt    = &ElmBasicType{name: "Int", codec: "int"}
-	elmString = &ElmBasicType{name: "String", codec: "string"}
+	elmInt    = &ElmBasicType{name: "Int", codec: "int", keyParser: "String.toInt", keyFormatter: "String.fromInt"}
+	elmString = &ElmBasicType{name: "String", codec: "string", keyParser: "Just", keyFormatter: "identity"}
+	elmUint   = &ElmBasicType{name: "Int", codec: "int", decoder: "decodeUint", keyParser: "parseUint", keyFormatter: "String.fromInt"}
+	elmPosix  = &ElmExternalType{name: "Time.Posix", decoder: "decodePosix", encoder: "posixEncoder"}
+	elmValue  = &ElmExternalType{name: "D.Value", decoder: "D.value", encoder: "identity"}
 )
 
 // ElmType represents a type in Elm.
 type ElmType interface {
-	Name() string
-	Decoder(prefix string) string
-	Encoder(prefix string) string
+	// TypeExpr returns the Elm type expression.
+	TypeExpr() *ElmExpr
+	// DecoderExpr returns the Elm JSON decoder expression for this type.
+	DecoderExpr(prefix string) *ElmExpr
+	// EncoderExpr returns the Elm JSON encoder expression for this type.
+	EncoderExpr(prefix string) *ElmExpr
 	Equal(other ElmType) bool
 	Nullable() bool
 }
 
+// ElmKeyType is implemented by Elm types that can be converted to and from JSON object keys.
+type ElmKeyType interface {
+	ElmType
+	// KeyParser returns an Elm function of type `String -> Maybe a`.
+	KeyParser() string
+	// KeyFormatter returns an Elm function of type `a -> String`.
+	KeyFormatter() string
+	// Comparable indicates whether this type may be used as an Elm Dict key.
+	Comparable() bool
+}
+
 func elmTypeName(t ElmType) string {
 	if t == nil {
 		return "<undefined>"
 	}
-	return t.Name()
+	return t.TypeExpr().String()
 }
 
 // ElmBasicType represents primitive types in Elm.
 type ElmBasicType struct {
-	name  string
-	codec string
+	name         string
+	codec        string
+	decoder      string // Overrides the codec for decoding.
+	keyParser    string
+	keyFormatter string
 }
 
 // Name returns the name of the Elm type.
@@ -42,6 +65,9 @@ func (t *ElmBasicType) Name() string {
 
 // Decoder returns the name of the Elm JSON encoder/decoder for this type.
 func (t *ElmBasicType) Decoder(prefix string) string {
+	if t.decoder != "" {
+		return t.decoder
+	}
 	return prefix + "." + t.codec
 }
 
@@ -50,11 +76,27 @@ func (t *ElmBasicType) Encoder(prefix string) string {
 	return prefix + "." + t.codec
 }
 
+// TypeExpr returns the Elm type expression.
+func (t *ElmBasicType) TypeExpr() *ElmExpr {
+	return elmRef(t.name)
+}
+
+// DecoderExpr returns the Elm JSON decoder expression for this type.
+func (t *ElmBasicType) DecoderExpr(prefix string) *ElmExpr {
+	return elmRef(t.Decoder(prefix))
+}
+
+// EncoderExpr returns the Elm JSON encoder expression for this type.
+func (t *ElmBasicType) EncoderExpr(prefix string) *ElmExpr {
+	return elmRef(t.Encoder(prefix))
+}
+
 // Equal tests for equality with another ElmType.
 func (t *ElmBasicType) Equal(other ElmType) bool {
 	if o, ok := other.(*ElmBasicType); ok {
 		return t.name == o.name &&
-			t.codec == o.codec
+			t.codec == o.codec &&
+			t.decoder == o.decoder
 	}
 	return false
 }
@@ -64,57 +106,296 @@ func (t *ElmBasicType) Nullable() bool {
 	return false
 }
 
+// KeyParser returns the Elm function converting a JSON object key into this type.
+func (t *ElmBasicType) KeyParser() string {
+	return t.keyParser
+}
+
+// KeyFormatter returns the Elm function converting this type into a JSON object key.
+func (t *ElmBasicType) KeyFormatter() string {
+	return t.keyFormatter
+}
+
+// Comparable indicates whether this type may be used as an Elm Dict key.
+func (t *ElmBasicType) Comparable() bool {
+	return true
+}
+
+// ElmExternalType represents an Elm type with a predefined decoder and encoder, such as a type
+// provided by an Elm package.
+type ElmExternalType struct {
+	name    string
+	decoder string
+	encoder string
+}
+
+// TypeExpr returns the Elm type expression.
+func (t *ElmExternalType) TypeExpr() *ElmExpr {
+	return elmSource(t.name)
+}
+
+// DecoderExpr returns the Elm JSON decoder expression for this type.
+func (t *ElmExternalType) DecoderExpr(prefix string) *ElmExpr {
+	return elmSource(t.decoder)
+}
+
+// EncoderExpr returns the Elm JSON encoder expression for this type.
+func (t *ElmExternalType) EncoderExpr(prefix string) *ElmExpr {
+	return elmSource(t.encoder)
+}
+
+// Equal tests for equality with another ElmType.
+func (t *ElmExternalType) Equal(other ElmType) bool {
+	if o, ok := other.(*ElmExternalType); ok {
+		return t.name == o.name &&
+			t.decoder == o.decoder &&
+			t.encoder == o.encoder
+	}
+	return false
+}
+
+// Nullable indicates whether this type can be nil.
+func (t *ElmExternalType) Nullable() bool {
+	return false
+}
+
 // ElmList represents a list of another type.
 type ElmList struct {
 	elem ElmType
+	nils NilPolicy
 }
 
-// Name returns the name of the Elm type.
-func (t *ElmList) Name() string {
-	return "List " + t.elem.Name()
+// TypeExpr returns the Elm type expression.
+func (t *ElmList) TypeExpr() *ElmExpr {
+	return elmApply("List", t.elem.TypeExpr())
 }
 
-// Decoder returns the name of the Elm JSON encoder/decoder for this type.
-func (t *ElmList) Decoder(prefix string) string {
-	return "(" + prefix + ".list " + t.elem.Decoder(prefix) + ")"
+// DecoderExpr returns the Elm JSON decoder expression for this type.
+func (t *ElmList) DecoderExpr(prefix string) *ElmExpr {
+	return nilDecoder(prefix, t.nils, elmList(), elmApply(prefix+".list", t.elem.DecoderExpr(prefix)))
 }
 
-// Encoder returns the name of the Elm JSON encoder/decoder for this type.
-func (t *ElmList) Encoder(prefix string) string {
-	return "(" + prefix + ".list " + t.elem.Encoder(prefix) + ")"
+// EncoderExpr returns the Elm JSON encoder expression for this type.
+func (t *ElmList) EncoderExpr(prefix string) *ElmExpr {
+	return nilEncoder(t.nils, "List.isEmpty", elmApply(prefix+".list", t.elem.EncoderExpr(prefix)))
 }
 
 // Equal tests for equality with another ElmType.
 func (t *ElmList) Equal(other ElmType) bool {
 	if o, ok := other.(*ElmList); ok {
-		return t.elem.Equal(o.elem)
+		return t.elem.Equal(o.elem) && t.nils == o.nils
 	}
 	return false
 }
 
-// Nullable indicates whether this type can be nil.
+// Nullable indicates whether this type can be nil.  Under the NilEmpty and NilNull policies, null
+// is decoded as an empty list instead.
 func (t *ElmList) Nullable() bool {
-	return true
+	return t.nils == NilMaybe
+}
+
+// nilDecoder wraps the decoder of a slice or map type to decode null as empty, unless nils are
+// represented by Maybe.
+func nilDecoder(prefix string, nils NilPolicy, empty, decoder *ElmExpr) *ElmExpr {
+	if nils == NilMaybe {
+		return decoder
+	}
+	return elmApply(prefix+".oneOf", elmList(elmApply(prefix+".null", empty), decoder))
+}
+
+// nilEncoder wraps the encoder of a slice or map type to encode empty as null under the NilNull
+// policy, isEmpty is the Elm function testing for emptiness.
+func nilEncoder(nils NilPolicy, isEmpty string, encoder *ElmExpr) *ElmExpr {
+	if nils != NilNull {
+		return encoder
+	}
+	return elmApply("nullIfEmpty", elmRef(isEmpty), encoder)
+}
+
+// ElmArray represents a Go array, a JSON array of fixed length.  Arrays of two or three elements
+// may be represented as Elm tuples.
+type ElmArray struct {
+	elem   ElmType
+	length int64
+	tuple  bool
+}
+
+// TypeExpr returns the Elm type expression.
+func (t *ElmArray) TypeExpr() *ElmExpr {
+	if t.tuple {
+		elems := make([]*ElmExpr, t.length)
+		for i := range elems {
+			elems[i] = t.elem.TypeExpr()
+		}
+		return elmTuple(elems...)
+	}
+	return elmApply("List", t.elem.TypeExpr())
+}
+
+// DecoderExpr returns the Elm JSON decoder expression for this type.
+func (t *ElmArray) DecoderExpr(prefix string) *ElmExpr {
+	if t.tuple {
+		return elmApply("decode"+pascalCase(t.tupleName()), t.elem.DecoderExpr(prefix))
+	}
+	return elmApply("fixedLength", elmRef(strconv.FormatInt(t.length, 10)),
+		elmApply(prefix+".list", t.elem.DecoderExpr(prefix)))
+}
+
+// EncoderExpr returns the Elm JSON encoder expression for this type.
+func (t *ElmArray) EncoderExpr(prefix string) *ElmExpr {
+	if t.tuple {
+		return elmApply(t.tupleName()+"Encoder", t.elem.EncoderExpr(prefix))
+	}
+	return elmApply(prefix+".list", t.elem.EncoderExpr(prefix))
+}
+
+// tupleName returns the name of the tuple helpers.
+func (t *ElmArray) tupleName() string {
+	if t.length == 2 {
+		return "pair"
+	}
+	return "triple"
+}
+
+// Equal tests for equality with another ElmType.
+func (t *ElmArray) Equal(other ElmType) bool {
+	if o, ok := other.(*ElmArray); ok {
+		return t.elem.Equal(o.elem) && t.length == o.length && t.tuple == o.tuple
+	}
+	return false
+}
+
+// Nullable indicates whether this type can be nil.
+func (t *ElmArray) Nullable() bool {
+	return false
+}
+
+// ElmBytes represents a Go byte slice, which encoding/json encodes as a base64 string.  It is
+// either left as the base64 String, or decoded into Elm Bytes.  Nil byte slices are null, like
+// other slices.
+type ElmBytes struct {
+	decoded bool
+	nils    NilPolicy
+}
+
+// TypeExpr returns the Elm type expression.
+func (t *ElmBytes) TypeExpr() *ElmExpr {
+	if t.decoded {
+		return elmRef("Bytes")
+	}
+	return elmString.TypeExpr()
+}
+
+// DecoderExpr returns the Elm JSON decoder expression for this type.
+func (t *ElmBytes) DecoderExpr(prefix string) *ElmExpr {
+	if t.decoded {
+		empty := elmApply("Bytes.Encode.encode", elmApply("Bytes.Encode.sequence", elmList()))
+		return nilDecoder(prefix, t.nils, empty, elmRef("decodeBase64"))
+	}
+	return nilDecoder(prefix, t.nils, elmRef(elmQuote("")), elmString.DecoderExpr(prefix))
+}
+
+// EncoderExpr returns the Elm JSON encoder expression for this type.
+func (t *ElmBytes) EncoderExpr(prefix string) *ElmExpr {
+	if t.decoded {
+		return nilEncoder(t.nils, "(Bytes.width >> (==) 0)", elmRef("base64Encoder"))
+	}
+	return nilEncoder(t.nils, "String.isEmpty", elmString.EncoderExpr(prefix))
+}
+
+// Equal tests for equality with another ElmType.
+func (t *ElmBytes) Equal(other ElmType) bool {
+	if o, ok := other.(*ElmBytes); ok {
+		return t.decoded == o.decoded && t.nils == o.nils
+	}
+	return false
+}
+
+// Nullable indicates whether this type can be nil.  Under the NilEmpty and NilNull policies, null
+// is decoded as empty instead.
+func (t *ElmBytes) Nullable() bool {
+	return t.nils == NilMaybe
+}
+
+// ElmDict represents a map of another type, encoded as a JSON object.  Maps with String keys are
+// decoded directly, other comparable keys are parsed from the object keys.  Elm Dicts require
+// comparable keys, so other key types are represented as a list of key-value pairs.
+type ElmDict struct {
+	key  ElmKeyType
+	elem ElmType
+	nils NilPolicy
+}
+
+// TypeExpr returns the Elm type expression.
+func (t *ElmDict) TypeExpr() *ElmExpr {
+	if !t.key.Comparable() {
+		return elmApply("List", elmTuple(t.key.TypeExpr(), t.elem.TypeExpr()))
+	}
+	return elmApply("Dict", t.key.TypeExpr(), t.elem.TypeExpr())
+}
+
+// DecoderExpr returns the Elm JSON decoder expression for this type.
+func (t *ElmDict) DecoderExpr(prefix string) *ElmExpr {
+	if t.stringKeys() {
+		return nilDecoder(prefix, t.nils, elmRef("Dict.empty"),
+			elmApply(prefix+".dict", t.elem.DecoderExpr(prefix)))
+	}
+	pairs := elmApply("decodeKeyedPairs", elmRef(t.key.KeyParser()), t.elem.DecoderExpr(prefix))
+	if !t.key.Comparable() {
+		return nilDecoder(prefix, t.nils, elmList(), pairs)
+	}
+	return nilDecoder(prefix, t.nils, elmRef("Dict.empty"),
+		elmApply(prefix+".map", elmRef("Dict.fromList"), pairs))
+}
+
+// EncoderExpr returns the Elm JSON encoder expression for this type.
+func (t *ElmDict) EncoderExpr(prefix string) *ElmExpr {
+	formatter := elmRef(t.key.KeyFormatter())
+	if !t.key.Comparable() {
+		return nilEncoder(t.nils, "List.isEmpty",
+			elmApply("keyedPairsEncoder", formatter, t.elem.EncoderExpr(prefix)))
+	}
+	return nilEncoder(t.nils, "Dict.isEmpty",
+		elmApply(prefix+".dict", formatter, t.elem.EncoderExpr(prefix)))
+}
+
+// stringKeys indicates the keys are represented by Elm Strings, and need no conversion.
+func (t *ElmDict) stringKeys() bool {
+	return t.key.Comparable() && t.key.KeyParser() == elmString.KeyParser()
+}
+
+// Equal tests for equality with another ElmType.
+func (t *ElmDict) Equal(other ElmType) bool {
+	if o, ok := other.(*ElmDict); ok {
+		return t.key.Equal(o.key) && t.elem.Equal(o.elem) && t.nils == o.nils
+	}
+	return false
 }
 
-// ElmPointer represents a pointer to an instance of another type.
+// Nullable indicates whether this type can be nil.  Under the NilEmpty and NilNull policies, null
+// is decoded as an empty dict instead.
+func (t *ElmDict) Nullable() bool {
+	return t.nils == NilMaybe
+}
+
+// ElmPointer represents a pointer to an instance of another type, which is a Maybe in Elm.
 type ElmPointer struct {
 	elem ElmType
 }
 
-// Name returns the name of the Elm type.
-func (t *ElmPointer) Name() string {
-	return t.elem.Name()
+// TypeExpr returns the Elm type expression.
+func (t *ElmPointer) TypeExpr() *ElmExpr {
+	return elmApply("Maybe", t.elem.TypeExpr())
 }
 
-// Decoder returns the name of the Elm JSON encoder/decoder for this type.
-func (t *ElmPointer) Decoder(prefix string) string {
-	return t.elem.Decoder(prefix)
+// DecoderExpr returns the Elm JSON decoder expression for this type.
+func (t *ElmPointer) DecoderExpr(prefix string) *ElmExpr {
+	return elmApply(prefix+".nullable", t.elem.DecoderExpr(prefix))
 }
 
-// Encoder returns the name of the Elm JSON encoder/decoder for this type.
-func (t *ElmPointer) Encoder(prefix string) string {
-	return t.elem.Encoder(prefix)
+// EncoderExpr returns the Elm JSON encoder expression for this type.
+func (t *ElmPointer) EncoderExpr(prefix string) *ElmExpr {
+	return elmApply("maybe", t.elem.EncoderExpr(prefix))
 }
 
 // Equal tests for equality with another ElmType.
@@ -130,18 +411,221 @@ func (t *ElmPointer) Nullable() bool {
 	return true
 }
 
+// ElmLazy represents a reference to a record that is still being converted, closing a cycle of
+// record types.  Elm decoders may not be defined directly in terms of themselves, so the
+// reference is decoded lazily.
+type ElmLazy struct {
+	elem ElmType // *ElmRecord, *ElmApplied or *ElmUnion.
+}
+
+// TypeExpr returns the Elm type expression.
+func (t *ElmLazy) TypeExpr() *ElmExpr {
+	return t.elem.TypeExpr()
+}
+
+// DecoderExpr returns the Elm JSON decoder expression for this type.
+func (t *ElmLazy) DecoderExpr(prefix string) *ElmExpr {
+	return elmApply(prefix+".lazy", elmLambda(t.elem.DecoderExpr(prefix)))
+}
+
+// EncoderExpr returns the Elm JSON encoder expression for this type.
+func (t *ElmLazy) EncoderExpr(prefix string) *ElmExpr {
+	return t.elem.EncoderExpr(prefix)
+}
+
+// Equal tests for equality with another ElmType.  Only the names of the records are compared,
+// comparing their fields would follow the cycle.
+func (t *ElmLazy) Equal(other ElmType) bool {
+	if o, ok := other.(*ElmLazy); ok {
+		return elmTypeName(t.elem) == elmTypeName(o.elem)
+	}
+	return false
+}
+
+// Nullable indicates whether this type can be nil.
+func (t *ElmLazy) Nullable() bool {
+	return t.elem.Nullable()
+}
+
+// ElmTypeVar represents a type parameter of a generic record as an Elm type variable.  The
+// decoder and encoder for a type variable are parameters of the record decoder and encoder.
+type ElmTypeVar struct {
+	name string
+}
+
+// typeVarName returns the Elm type variable name for the type parameter at index.
+func typeVarName(index int) string {
+	if index < 26 {
+		return string(rune('a' + index))
+	}
+	return "t" + strconv.Itoa(index)
+}
+
+// TypeExpr returns the Elm type expression.
+func (t *ElmTypeVar) TypeExpr() *ElmExpr {
+	return elmRef(t.name)
+}
+
+// DecoderExpr returns the Elm JSON decoder expression for this type.
+func (t *ElmTypeVar) DecoderExpr(prefix string) *ElmExpr {
+	return elmRef(t.name + "Decoder")
+}
+
+// EncoderExpr returns the Elm JSON encoder expression for this type.
+func (t *ElmTypeVar) EncoderExpr(prefix string) *ElmExpr {
+	return elmRef("encode" + strings.ToUpper(t.name[:1]) + t.name[1:])
+}
+
+// Equal tests for equality with another ElmType.
+func (t *ElmTypeVar) Equal(other ElmType) bool {
+	if o, ok := other.(*ElmTypeVar); ok {
+		return t.name == o.name
+	}
+	return false
+}
+
+// Nullable indicates whether this type can be nil.
+func (t *ElmTypeVar) Nullable() bool {
+	return false
+}
+
+// ElmApplied represents a generic record applied to type arguments, such as `Page Int`.
+type ElmApplied struct {
+	record *ElmRecord
+	args   []ElmType
+}
+
+// TypeExpr returns the Elm type expression.
+func (t *ElmApplied) TypeExpr() *ElmExpr {
+	var args []*ElmExpr
+	for _, arg := range t.args {
+		args = append(args, arg.TypeExpr())
+	}
+	return elmApply(t.record.Name(), args...)
+}
+
+// DecoderExpr returns the Elm JSON decoder expression for this type.
+func (t *ElmApplied) DecoderExpr(prefix string) *ElmExpr {
+	var args []*ElmExpr
+	for _, arg := range t.args {
+		args = append(args, arg.DecoderExpr(prefix))
+	}
+	return elmApply(t.record.Decoder(prefix), args...)
+}
+
+// EncoderExpr returns the Elm JSON encoder expression for this type.
+func (t *ElmApplied) EncoderExpr(prefix string) *ElmExpr {
+	var args []*ElmExpr
+	for _, arg := range t.args {
+		args = append(args, arg.EncoderExpr(prefix))
+	}
+	return elmApply(t.record.Encoder(prefix), args...)
+}
+
+// Equal tests for equality with another ElmType.  Only the names of the records are compared,
+// as the record may be recursive.
+func (t *ElmApplied) Equal(other ElmType) bool {
+	if o, ok := other.(*ElmApplied); ok {
+		if t.record.Name() != o.record.Name() || len(t.args) != len(o.args) {
+			return false
+		}
+		for i, arg := range t.args {
+			if !arg.Equal(o.args[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Nullable indicates whether this type can be nil.
+func (t *ElmApplied) Nullable() bool {
+	return false
+}
+
+// ElmStringified represents a value encoded inside a JSON string, as by the `,string` option.
+type ElmStringified struct {
+	elem ElmType
+}
+
+// TypeExpr returns the Elm type expression.
+func (t *ElmStringified) TypeExpr() *ElmExpr {
+	return t.elem.TypeExpr()
+}
+
+// DecoderExpr returns the Elm JSON decoder expression for this type.
+func (t *ElmStringified) DecoderExpr(prefix string) *ElmExpr {
+	return elmApply("stringified", t.elem.DecoderExpr(prefix))
+}
+
+// EncoderExpr returns the Elm JSON encoder expression for this type.
+func (t *ElmStringified) EncoderExpr(prefix string) *ElmExpr {
+	return elmApply("stringifiedEncoder", t.elem.EncoderExpr(prefix))
+}
+
+// Equal tests for equality with another ElmType.
+func (t *ElmStringified) Equal(other ElmType) bool {
+	if o, ok := other.(*ElmStringified); ok {
+		return t.elem.Equal(o.elem)
+	}
+	return false
+}
+
+// Nullable indicates whether this type can be nil.
+func (t *ElmStringified) Nullable() bool {
+	return false
+}
+
+// aliasType is implemented by *types.Alias, which newer Go releases use to represent alias
+// declarations such as `any`.
+type aliasType interface {
+	types.Type
+	Obj() *types.TypeName
+	Rhs() types.Type
+}
+
 // ElmTypeResolver maintains a cache of Go to Elm type conversions.
 type ElmTypeResolver struct {
-	resolved map[string]*ElmRecord
-	ordered  []*ElmRecord
-	renames  TypeNamePairs
+	resolved      map[string]*ElmRecord // Keyed by typeKey.
+	pending       map[string]*ElmRecord // Records being converted.
+	ordered       []*ElmRecord
+	enums         map[string]*ElmEnum
+	ordEnums      []*ElmEnum
+	wrappers      map[string]*ElmWrapper
+	ordWraps      []*ElmWrapper
+	unions        map[string]*ElmUnion
+	ordUnions     []*ElmUnion
+	pendingUnions map[string]*ElmUnion // Unions being converted.
+	ctors         map[string]bool
+	enumCtors     map[string]*ElmEnum // Enum constructors to their enum.
+	names         map[string]string   // Elm type names to the Go types they were generated from.
+	renames       TypeNamePairs
+	options       Options
+	imports       map[string]bool
+	helpers       map[string]bool
+	notes         []string
+	record        string        // Elm name of the record being converted.
+	params        []*ElmTypeVar // Type parameters of the record being converted.
+	anonName      string        // Elm name for anonymous structs in the field being converted.
 }
 
 // NewResolver creates an empty resolver.
-func NewResolver(renames TypeNamePairs) *ElmTypeResolver {
+func NewResolver(renames TypeNamePairs, options Options) *ElmTypeResolver {
 	return &ElmTypeResolver{
-		resolved: make(map[string]*ElmRecord),
-		renames:  renames,
+		resolved:      make(map[string]*ElmRecord),
+		pending:       make(map[string]*ElmRecord),
+		enums:         make(map[string]*ElmEnum),
+		wrappers:      make(map[string]*ElmWrapper),
+		unions:        make(map[string]*ElmUnion),
+		pendingUnions: make(map[string]*ElmUnion),
+		ctors:         make(map[string]bool),
+		enumCtors:     make(map[string]*ElmEnum),
+		names:         make(map[string]string),
+		renames:       renames,
+		options:       options,
+		imports:       make(map[string]bool),
+		helpers:       make(map[string]bool),
 	}
 }
 
@@ -154,9 +638,11 @@ func (r *ElmTypeResolver) Convert(goType types.Type) (ElmType, error) {
 			return elmBool, nil
 		case types.Float32, types.Float64:
 			return elmFloat, nil
-		case types.Int, types.Int8, types.Int16, types.Int32, types.Int64,
-			types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64:
+		case types.Int, types.Int8, types.Int16, types.Int32, types.Int64:
 			return elmInt, nil
+		case types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64:
+			r.helpers["uint"] = true
+			return elmUint, nil
 		case types.String:
 			return elmString, nil
 		}
@@ -165,42 +651,773 @@ func (r *ElmTypeResolver) Convert(goType types.Type) (ElmType, error) {
 		if err != nil {
 			return nil, err
 		}
+		if _, ok := elemType.(*ElmPointer); ok {
+			// Null decodes as a nil outer pointer, so nested pointers are a single Maybe.
+			return elemType, nil
+		}
 		return &ElmPointer{elem: elemType}, nil
 	case *types.Slice:
-		elemType, err := r.Convert(t.Elem())
+		if isByteSlice(t) {
+			if r.options.Bytes {
+				r.imports["Bytes exposing (Bytes)"] = true
+				r.imports["Bytes.Decode"] = true
+				r.imports["Bytes.Encode"] = true
+				r.helpers["base64"] = true
+			}
+			r.checkNils()
+			return &ElmBytes{decoded: r.options.Bytes, nils: r.options.Nils}, nil
+		}
+		elemType, err := r.convertElem(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		r.checkNils()
+		return &ElmList{elem: elemType, nils: r.options.Nils}, nil
+	case *types.Array:
+		elemType, err := r.convertElem(t.Elem())
 		if err != nil {
 			return nil, err
 		}
-		return &ElmList{elem: elemType}, nil
+		array := &ElmArray{elem: elemType, length: t.Len()}
+		r.helpers["fixedLength"] = true
+		if r.options.Tuples && (array.length == 2 || array.length == 3) {
+			array.tuple = true
+			r.helpers["tuples"] = true
+		}
+		return array, nil
+	case *types.Map:
+		keyType, err := r.convertKey(t.Key())
+		if err != nil {
+			return nil, err
+		}
+		elemType, err := r.convertElem(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		r.checkNils()
+		dict := &ElmDict{key: keyType, elem: elemType, nils: r.options.Nils}
+		if keyType.Comparable() {
+			r.imports["Dict exposing (Dict)"] = true
+		}
+		if !dict.stringKeys() {
+			r.helpers["keyedPairs"] = true
+			how := "keys parsed with " + keyType.KeyParser()
+			if !keyType.Comparable() {
+				how = elmTypeName(keyType) + " is not comparable"
+			}
+			r.note(qualifiedTypeString(t) + " is represented as " + elmTypeName(dict) + ", " + how)
+		}
+		return dict, nil
+	case *types.Interface:
+		if t.Empty() {
+			return elmValue, nil
+		}
+	case *types.Struct:
+		record, err := r.resolveAnonymous(t)
+		if err != nil || len(record.params) == 0 {
+			return record, err
+		}
+		applied := &ElmApplied{record: record}
+		for _, p := range record.params {
+			applied.args = append(applied.args, p)
+		}
+		return applied, nil
 	case *types.Named:
-		goName := t.Obj().Name()
+		if mapped := r.mapping(t); mapped != nil {
+			return mapped, nil
+		}
+		if isNamed(t, "time", "Time") {
+			r.imports["Time"] = true
+			r.helpers["posix"] = true
+			return elmPosix, nil
+		}
+		if isNamed(t, "encoding/json", "RawMessage") || isNamed(t, "encoding/json/jsontext", "Value") {
+			return elmValue, nil
+		}
 		switch u := t.Underlying().(type) {
 		case *types.Struct:
-			return r.resolveRecord(goName, u)
+			if t.TypeArgs().Len() > 0 {
+				return r.resolveApplied(t)
+			}
+			if record := r.pending[typeKey(t)]; record != nil {
+				return r.lazyRecord(record, record)
+			}
+			return r.resolveRecord(t, u)
+		case *types.Array, *types.Slice, *types.Map:
+			return r.Convert(u)
+		case *types.Basic:
+			consts := enumConstants(t)
+			if u.Info()&(types.IsString|types.IsInteger) != 0 && isEnum(consts) &&
+				!r.options.Wrappers.has(t) {
+				return r.resolveEnum(t, consts)
+			}
+			return r.resolveWrapper(t, u)
+		case *types.Interface:
+			if _, ok := unionOptions(r.options.Unions, t); !ok && u.Empty() {
+				return elmValue, nil
+			}
+			return r.resolveUnion(t)
 		}
+	case *types.TypeParam:
+		return &ElmTypeVar{name: typeVarName(t.Index())}, nil
+	case aliasType:
+		return r.Convert(t.Rhs())
 	}
 	return nil, errors.Errorf("don't know how to handle Go type %s (%T)", goType, goType)
 }
 
+// convertElem translates the element type of a slice, array or map.  Elements that may be null
+// are wrapped in a Maybe, as record fields are.
+func (r *ElmTypeResolver) convertElem(goType types.Type) (ElmType, error) {
+	elemType, err := r.Convert(goType)
+	if err != nil {
+		return nil, err
+	}
+	if _, isPointer := elemType.(*ElmPointer); elemType.Nullable() && !isPointer {
+		return &ElmPointer{elem: elemType}, nil
+	}
+	return elemType, nil
+}
+
+// mapping returns the Elm type configured for the named type by Options.Mappings, which may be
+// keyed by its package path or name, or nil.
+func (r *ElmTypeResolver) mapping(t *types.Named) *ElmExternalType {
+	m, ok := r.options.Mappings[typeKey(t)]
+	if !ok {
+		m, ok = r.options.Mappings[qualifiedTypeString(t)]
+	}
+	if !ok {
+		return nil
+	}
+	if m.Import != "" {
+		r.imports[m.Import] = true
+	}
+	return &ElmExternalType{name: m.Type, decoder: m.Decoder, encoder: m.Encoder}
+}
+
+// Stringify wraps elemType, converted from goType, to be encoded inside a JSON string.  Like
+// encoding/json, the `,string` option only applies to strings, numbers and bools, and pointers to
+// them; other types are returned unchanged.  The helpers are registered by checkStringified, once
+// the Int64 policy has been applied.
+func (r *ElmTypeResolver) Stringify(goType types.Type, elmType ElmType) ElmType {
+	if p, ok := goType.Underlying().(*types.Pointer); ok {
+		goType = p.Elem()
+	}
+	b, ok := goType.Underlying().(*types.Basic)
+	if !ok || b.Info()&(types.IsBoolean|types.IsInteger|types.IsFloat|types.IsString) == 0 {
+		return elmType
+	}
+	if p, ok := elmType.(*ElmPointer); ok {
+		return &ElmPointer{elem: &ElmStringified{elem: p.elem}}
+	}
+	return &ElmStringified{elem: elmType}
+}
+
+// checkStringified registers the helpers of a stringified record field type.
+func (r *ElmTypeResolver) checkStringified(elmType ElmType) {
+	if p, ok := elmType.(*ElmPointer); ok {
+		elmType = p.elem
+	}
+	if _, ok := elmType.(*ElmStringified); ok {
+		r.helpers["stringified"] = true
+	}
+}
+
+// CheckInt64 applies the Int64 policy to a record field converted from goType.  Stringified
+// 64-bit integers become Elm Strings under the Int64String policy, other fields holding 64-bit
+// integers are reported with a warning.
+func (r *ElmTypeResolver) CheckInt64(field string, goType types.Type, elmType ElmType) ElmType {
+	if r.options.Int64 == Int64Int || !has64BitInt(goType) {
+		return elmType
+	}
+	if r.options.Int64 == Int64String {
+		switch t := elmType.(type) {
+		case *ElmStringified:
+			if isElmInt(t.elem) {
+				return elmString
+			}
+		case *ElmPointer:
+			if s, ok := t.elem.(*ElmStringified); ok && isElmInt(s.elem) {
+				return &ElmPointer{elem: elmString}
+			}
+		}
+	}
+	logger.Warn().
+		Str("field", field).
+		Str("goType", qualifiedTypeString(goType)).
+		Msg("64-bit integer may exceed the precision of Elm Int, " +
+			"consider the `,string` option with -int64=string")
+	return elmType
+}
+
+// checkNils records the helpers required by the Nils policy for a slice or map.
+func (r *ElmTypeResolver) checkNils() {
+	if r.options.Nils == NilNull {
+		r.helpers["nullIfEmpty"] = true
+	}
+}
+
 // CachedRecords returns slice of resolved Elm records.
 func (r *ElmTypeResolver) CachedRecords() []*ElmRecord {
 	return r.ordered
 }
 
-// resolveRecord converts the struct to an Elm record, or returns the cached version.
-func (r *ElmTypeResolver) resolveRecord(goName string, stype *types.Struct) (*ElmRecord, error) {
-	if record := r.resolved[goName]; record != nil {
+// convertKey translates a Go map key type into an Elm type, following the encoding/json rules
+// for converting map keys into JSON object keys.
+func (r *ElmTypeResolver) convertKey(goType types.Type) (ElmKeyType, error) {
+	b, isBasic := goType.Underlying().(*types.Basic)
+	if isBasic && b.Info()&types.IsString != 0 {
+		return r.convertBasicKey(goType)
+	}
+	if isTextMarshaler(goType) {
+		r.note(qualifiedTypeString(goType) + " map keys are represented by their MarshalText String")
+		return elmString, nil
+	}
+	if isBasic && b.Info()&types.IsInteger != 0 {
+		return r.convertBasicKey(goType)
+	}
+	return nil, errors.Errorf("map key type %s is not supported", goType)
+}
+
+// convertBasicKey translates a Go string or integer map key type into an Elm type.
+func (r *ElmTypeResolver) convertBasicKey(goType types.Type) (ElmKeyType, error) {
+	elmType, err := r.Convert(goType)
+	if err != nil {
+		return nil, err
+	}
+	keyType, ok := elmType.(ElmKeyType)
+	if !ok {
+		return nil, errors.Errorf("map key type %s converted to %s, which cannot be a key",
+			goType, elmTypeName(elmType))
+	}
+	return keyType, nil
+}
+
+// note records a remark about the conversion, to be included in the generated Elm module.
+func (r *ElmTypeResolver) note(s string) {
+	for _, n := range r.notes {
+		if n == s {
+			return
+		}
+	}
+	r.notes = append(r.notes, s)
+}
+
+// Notes returns remarks about the conversion, in the order they were recorded.
+func (r *ElmTypeResolver) Notes() []string {
+	return r.notes
+}
+
+// Helpers returns the sorted names of Elm helper functions required by the resolved types.
+func (r *ElmTypeResolver) Helpers() []string {
+	helpers := make([]string, 0, len(r.helpers))
+	for h := range r.helpers {
+		helpers = append(helpers, h)
+	}
+	sort.Strings(helpers)
+	return helpers
+}
+
+// CachedEnums returns slice of resolved Elm custom types.
+func (r *ElmTypeResolver) CachedEnums() []*ElmEnum {
+	return r.ordEnums
+}
+
+// CachedUnions returns slice of resolved Elm tagged unions.
+func (r *ElmTypeResolver) CachedUnions() []*ElmUnion {
+	return r.ordUnions
+}
+
+// CachedWrappers returns slice of resolved Elm type aliases and opaque types.
+func (r *ElmTypeResolver) CachedWrappers() []*ElmWrapper {
+	return r.ordWraps
+}
+
+// Imports returns the sorted list of additional Elm imports required by the resolved types.
+func (r *ElmTypeResolver) Imports() []string {
+	imports := make([]string, 0, len(r.imports))
+	for imp := range r.imports {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+	return imports
+}
+
+// ResolveRoot converts the root struct to an Elm record.  The root record is not included in
+// CachedRecords.
+func (r *ElmTypeResolver) ResolveRoot(t *types.Named) (*ElmRecord, error) {
+	stype, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return nil, errors.Errorf("%s type is %T, want *types.Struct", t, t.Underlying())
+	}
+	record, err := r.resolveRecord(t, stype)
+	if err != nil {
+		return nil, err
+	}
+	record.root = true
+	nested := r.ordered[:0]
+	for _, rec := range r.ordered {
+		if rec != record {
+			nested = append(nested, rec)
+		}
+	}
+	r.ordered = nested
+	return record, nil
+}
+
+// resolveRecord converts the struct to an Elm record, or returns the cached version.  The record
+// is registered as pending during conversion, so that references back to it can be detected.
+func (r *ElmTypeResolver) resolveRecord(t *types.Named, stype *types.Struct) (*ElmRecord, error) {
+	key := typeKey(t)
+	if record := r.resolved[key]; record != nil {
+		return record, nil
+	}
+	name, err := r.elmName(t)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.recordCtor(name); err != nil {
+		return nil, err
+	}
+	record := &ElmRecord{name: name}
+	for i := 0; i < t.TypeParams().Len(); i++ {
+		record.params = append(record.params, &ElmTypeVar{name: typeVarName(i)})
+	}
+	r.pending[key] = record
+	defer delete(r.pending, key)
+	defer r.enterRecord(name, record.params)()
+	converted, err := recordFromStruct(r, stype, t.Obj().Name())
+	if err != nil {
+		return nil, err
+	}
+	record.Fields = converted.Fields
+	logger.Debug().
+		Str("name", key).
+		Str("type", elmTypeName(record)).
+		Msg("Caching resolved type")
+	r.resolved[key] = record
+	r.ordered = append(r.ordered, record)
+	return record, nil
+}
+
+// resolveAnonymous converts an anonymous struct to an Elm record, or returns the cached version.
+// The record is named after the record and field holding it, such as ResponseMeta.  Identical
+// anonymous structs share a record.  An anonymous struct using the type parameters of a generic
+// record takes all of its type variables, and is only shared within that record.
+func (r *ElmTypeResolver) resolveAnonymous(t *types.Struct) (*ElmRecord, error) {
+	key := types.TypeString(t, nil)
+	var params []*ElmTypeVar
+	if usesTypeParams(t) {
+		key = r.record + " " + key
+		params = r.params
+	}
+	if record := r.resolved[key]; record != nil {
 		return record, nil
 	}
-	record, err := recordFromStruct(r, stype, goName)
+	if r.anonName == "" {
+		return nil, errors.Errorf("anonymous struct %s is not held by a record field",
+			qualifiedTypeString(t))
+	}
+	name, renamed := r.renames[r.anonName]
+	if !renamed {
+		name = r.anonName
+	}
+	if other, taken := r.names[name]; taken {
+		return nil, errors.Errorf("%s and anonymous struct %s are both named %s in Elm, rename "+
+			"one of them with %s:<name>", other, r.anonName, name, r.anonName)
+	}
+	r.names[name] = "anonymous struct " + r.anonName
+	if err := r.recordCtor(name); err != nil {
+		return nil, err
+	}
+	defer r.enterRecord(name, params)()
+	record, err := recordFromStruct(r, t, r.anonName)
 	if err != nil {
 		return nil, err
 	}
+	record.name = name
+	record.params = params
 	logger.Debug().
-		Str("name", goName).
+		Str("name", key).
 		Str("type", elmTypeName(record)).
 		Msg("Caching resolved type")
-	r.resolved[goName] = record
+	r.resolved[key] = record
 	r.ordered = append(r.ordered, record)
 	return record, nil
 }
+
+// enterRecord sets the record being converted, and returns a function restoring the previous one.
+func (r *ElmTypeResolver) enterRecord(name string, params []*ElmTypeVar) func() {
+	record, recordParams, anonName := r.record, r.params, r.anonName
+	r.record, r.params = name, params
+	return func() {
+		r.record, r.params, r.anonName = record, recordParams, anonName
+	}
+}
+
+// usesTypeParams indicates whether the type refers to a type parameter.
+func usesTypeParams(t types.Type) bool {
+	switch t := t.(type) {
+	case *types.TypeParam:
+		return true
+	case *types.Pointer:
+		return usesTypeParams(t.Elem())
+	case *types.Slice:
+		return usesTypeParams(t.Elem())
+	case *types.Array:
+		return usesTypeParams(t.Elem())
+	case *types.Map:
+		return usesTypeParams(t.Key()) || usesTypeParams(t.Elem())
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if usesTypeParams(t.Field(i).Type()) {
+				return true
+			}
+		}
+	case *types.Named:
+		for i := 0; i < t.TypeArgs().Len(); i++ {
+			if usesTypeParams(t.TypeArgs().At(i)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// elmName reserves the Elm type name for the named Go type.  Renames may be qualified by package
+// name, such as `billing.Address:Address`.  When Go types from different packages share a name,
+// later types are prefixed with their package name; clashes that remain are reported as errors.
+func (r *ElmTypeResolver) elmName(t *types.Named) (string, error) {
+	obj := t.Obj()
+	goType := qualifiedTypeString(t)
+	name, renamed := r.renames[goType]
+	if !renamed {
+		name, renamed = r.renames[obj.Name()]
+	}
+	if !renamed {
+		name = pascalCase(obj.Name())
+	}
+	other, taken := r.names[name]
+	if taken && !renamed && obj.Pkg() != nil {
+		prefixed := pascalCase(obj.Pkg().Name()) + name
+		r.note(goType + " is named " + prefixed + ", " + name + " is taken by " + other)
+		name = prefixed
+		other, taken = r.names[name]
+	}
+	if taken {
+		return "", errors.Errorf("%s and %s are both named %s in Elm, rename one of them with %s:<name>",
+			other, goType, name, goType)
+	}
+	r.names[name] = goType
+	return name, nil
+}
+
+// resolveApplied converts an instantiated generic struct into its generic Elm record applied to
+// the converted type arguments.  Every instantiation shares the generic record.
+func (r *ElmTypeResolver) resolveApplied(t *types.Named) (ElmType, error) {
+	applied := &ElmApplied{}
+	for i := 0; i < t.TypeArgs().Len(); i++ {
+		arg, err := r.Convert(t.TypeArgs().At(i))
+		if err != nil {
+			return nil, err
+		}
+		applied.args = append(applied.args, arg)
+	}
+	origin := t.Origin()
+	if record := r.pending[typeKey(origin)]; record != nil {
+		applied.record = record
+		return r.lazyRecord(record, applied)
+	}
+	record, err := r.resolveRecord(origin, origin.Underlying().(*types.Struct))
+	if err != nil {
+		return nil, err
+	}
+	applied.record = record
+	return applied, nil
+}
+
+// lazyRecord returns a lazy reference to a record that is still being converted, elem is the
+// record or its application to type arguments.  Elm type aliases cannot be recursive, so the
+// record becomes a custom type wrapping its fields.
+func (r *ElmTypeResolver) lazyRecord(record *ElmRecord, elem ElmType) (*ElmLazy, error) {
+	record.Recursive = true
+	return &ElmLazy{elem: elem}, nil
+}
+
+// recordCtor reserves the constructor of a record, which is named after the record type alias,
+// or the custom type of a recursive record.  An enum constructor already using the name is
+// prefixed with its type name, as later clashing enum constructors are.
+func (r *ElmTypeResolver) recordCtor(name string) error {
+	if enum := r.enumCtors[name]; enum != nil {
+		prefixed := enum.name + name
+		if r.ctors[prefixed] || r.names[prefixed] != "" {
+			return errors.Errorf("constructor %s of %s is already defined", prefixed, enum.name)
+		}
+		for _, v := range enum.Values {
+			if v.Constructor == name {
+				v.Constructor = prefixed
+			}
+		}
+		delete(r.enumCtors, name)
+		r.enumCtors[prefixed] = enum
+		r.ctors[prefixed] = true
+	} else if r.ctors[name] {
+		return errors.Errorf("constructor %s is already defined, rename record %s with %s:<name>",
+			name, name, name)
+	}
+	r.ctors[name] = true
+	return nil
+}
+
+// resolveEnum converts the named type and its constants to an Elm custom type, or returns the
+// cached version.  Constructors share a namespace across the module, so clashing constructors are
+// prefixed with the type name.
+func (r *ElmTypeResolver) resolveEnum(t *types.Named, consts []*types.Const) (*ElmEnum, error) {
+	goName := t.Obj().Name()
+	key := typeKey(t)
+	if enum := r.enums[key]; enum != nil {
+		return enum, nil
+	}
+	name, err := r.elmName(t)
+	if err != nil {
+		return nil, err
+	}
+	enum, err := enumFromConstants(name, t, consts)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range enum.Values {
+		// Record type aliases are constructors too.
+		if r.ctors[v.Constructor] || r.names[v.Constructor] != "" {
+			v.Constructor = enum.name + v.Constructor
+		}
+		if r.ctors[v.Constructor] || r.names[v.Constructor] != "" {
+			return nil, errors.Errorf("constructor %s of %s is already defined", v.Constructor, goName)
+		}
+		r.ctors[v.Constructor] = true
+		r.enumCtors[v.Constructor] = enum
+	}
+	logger.Debug().
+		Str("name", key).
+		Str("type", elmTypeName(enum)).
+		Msg("Caching resolved type")
+	r.enums[key] = enum
+	r.ordEnums = append(r.ordEnums, enum)
+	return enum, nil
+}
+
+// resolveWrapper converts the named basic type to an Elm type alias or opaque type, or returns
+// the cached version.
+func (r *ElmTypeResolver) resolveWrapper(t *types.Named, u *types.Basic) (*ElmWrapper, error) {
+	goName := t.Obj().Name()
+	key := typeKey(t)
+	if wrapper := r.wrappers[key]; wrapper != nil {
+		return wrapper, nil
+	}
+	elmType, err := r.Convert(u)
+	if err != nil {
+		return nil, err
+	}
+	name, err := r.elmName(t)
+	if err != nil {
+		return nil, err
+	}
+	wrapper := &ElmWrapper{
+		name:   name,
+		basic:  elmType.(*ElmBasicType),
+		opaque: r.options.Opaque,
+	}
+	if wrapper.opaque {
+		if r.ctors[wrapper.name] {
+			return nil, errors.Errorf("constructor %s of %s is already defined", wrapper.name, goName)
+		}
+		r.ctors[wrapper.name] = true
+	}
+	logger.Debug().
+		Str("name", key).
+		Str("type", elmTypeName(wrapper)).
+		Msg("Caching resolved type")
+	r.wrappers[key] = wrapper
+	r.ordWraps = append(r.ordWraps, wrapper)
+	return wrapper, nil
+}
+
+// typeKey identifies a named type by its package path and name.
+func typeKey(t *types.Named) string {
+	obj := t.Obj()
+	if obj.Pkg() == nil {
+		return obj.Name()
+	}
+	return obj.Pkg().Path() + "." + obj.Name()
+}
+
+// resolveUnion converts the interface and the structs implementing it to an Elm tagged union,
+// or returns the cached version.  The discriminator field is dropped from the records of the
+// implementing structs, the union encoder writes it instead.
+func (r *ElmTypeResolver) resolveUnion(t *types.Named) (ElmType, error) {
+	key := typeKey(t)
+	if union := r.unions[key]; union != nil {
+		return union, nil
+	}
+	if union := r.pendingUnions[key]; union != nil {
+		return &ElmLazy{elem: union}, nil
+	}
+	config, ok := unionOptions(r.options.Unions, t)
+	if !ok {
+		return nil, errNotUnion(t)
+	}
+	members := unionMembers(t)
+	if len(members) == 0 {
+		return nil, errors.Errorf("no structs implement union %s", qualifiedTypeString(t))
+	}
+	name, err := r.elmName(t)
+	if err != nil {
+		return nil, err
+	}
+	union := &ElmUnion{name: name, Discriminator: config.Discriminator}
+	r.pendingUnions[key] = union
+	defer delete(r.pendingUnions, key)
+	for _, m := range members {
+		payload, err := r.Convert(m)
+		if err != nil {
+			return nil, err
+		}
+		tag := config.Tags[m.Obj().Name()]
+		if tag == "" {
+			tag = m.Obj().Name()
+		}
+		ctor := name + elmTypeName(payload)
+		if r.ctors[ctor] || r.names[ctor] != "" {
+			return nil, errors.Errorf("constructor %s of %s is already defined", ctor, name)
+		}
+		r.ctors[ctor] = true
+		union.Variants = append(union.Variants, &ElmVariant{
+			Constructor: ctor,
+			Payload:     payload,
+			Tag:         elmQuote(tag),
+		})
+	}
+	r.helpers["tagged"] = true
+	logger.Debug().
+		Str("name", key).
+		Str("type", elmTypeName(union)).
+		Msg("Caching resolved type")
+	r.unions[key] = union
+	r.ordUnions = append(r.ordUnions, union)
+	return union, nil
+}
+
+// zeroValue returns the Elm value of the Go zero value of t, which encoding/json considers empty,
+// or nil if t has none.
+func zeroValue(t ElmType) *ElmExpr {
+	switch t := t.(type) {
+	case *ElmBasicType:
+		switch t.codec {
+		case "bool":
+			return elmRef("False")
+		case "string":
+			return elmRef(elmQuote(""))
+		case "float":
+			return elmRef("0.0")
+		}
+		return elmRef("0")
+	case *ElmWrapper:
+		if t.opaque {
+			return elmApply(t.name, zeroValue(t.basic))
+		}
+		return zeroValue(t.basic)
+	case *ElmStringified:
+		return zeroValue(t.elem)
+	case *ElmBytes:
+		if !t.decoded {
+			return zeroValue(elmString)
+		}
+	case *ElmList:
+		return elmList()
+	case *ElmDict:
+		if t.key.Comparable() {
+			return elmRef("Dict.empty")
+		}
+		return elmList()
+	case *ElmRecord:
+		if len(t.params) > 0 {
+			return nil
+		}
+		names := make([]string, len(t.Fields))
+		values := make([]*ElmExpr, len(t.Fields))
+		for i, f := range t.Fields {
+			names[i] = f.ElmName
+			if values[i] = f.zeroValue(); values[i] == nil {
+				return nil
+			}
+		}
+		if t.Recursive {
+			return elmApply(t.name, elmRecord(names, values))
+		}
+		return elmRecord(names, values)
+	}
+	return nil
+}
+
+// has64BitInt tests if t holds a 64-bit integer, directly or through pointers, slices, arrays,
+// maps and named types.
+func has64BitInt(t types.Type) bool {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return u.Kind() == types.Int64 || u.Kind() == types.Uint64
+	case *types.Pointer:
+		return has64BitInt(u.Elem())
+	case *types.Slice:
+		return has64BitInt(u.Elem())
+	case *types.Array:
+		return has64BitInt(u.Elem())
+	case *types.Map:
+		return has64BitInt(u.Key()) || has64BitInt(u.Elem())
+	}
+	return false
+}
+
+// isByteSlice tests if encoding/json encodes t as a base64 string: a slice of bytes, which do not
+// implement their own marshaling.
+func isByteSlice(t *types.Slice) bool {
+	b, ok := t.Elem().Underlying().(*types.Basic)
+	if !ok || b.Kind() != types.Uint8 {
+		return false
+	}
+	p := types.NewPointer(t.Elem())
+	obj, _, _ := types.LookupFieldOrMethod(p, false, nil, "MarshalJSON")
+	return obj == nil && !isTextMarshaler(p)
+}
+
+// isElmInt tests if t is a signed or unsigned Elm Int.
+func isElmInt(t ElmType) bool {
+	return t == elmInt || t == elmUint
+}
+
+// isString tests if the underlying type of t is a Go string.
+func isString(t types.Type) bool {
+	b, ok := t.Underlying().(*types.Basic)
+	return ok && b.Info()&types.IsString != 0
+}
+
+// isNamed tests if t is the named type pkgPath.name.
+func isNamed(t *types.Named, pkgPath, name string) bool {
+	obj := t.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == pkgPath && obj.Name() == name
+}
+
+// isTextMarshaler tests if t implements encoding.TextMarshaler.
+func isTextMarshaler(t types.Type) bool {
+	obj, _, _ := types.LookupFieldOrMethod(t, false, nil, "MarshalText")
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return false
+	}
+	sig := fn.Type().(*types.Signature)
+	return sig.Params().Len() == 0 && sig.Results().Len() == 2
+}
+
+// qualifiedTypeString formats t using package names rather than paths.
+func qualifiedTypeString(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string { return p.Name() })
+}
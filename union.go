@@ -0,0 +1,151 @@
+package main
+
+import (
+	"go/types"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// ElmUnion represents an Elm custom type generated from a Go interface and the structs
+// implementing it.  The JSON objects carry a discriminator field naming their variant.
+type ElmUnion struct {
+	name          string
+	Discriminator string // JSON name of the discriminator field.
+	Variants      []*ElmVariant
+}
+
+// ElmVariant is a constructor of a tagged union, wrapping the record of an implementing struct.
+type ElmVariant struct {
+	Constructor string
+	Payload     ElmType // *ElmRecord, or *ElmLazy for records still being converted.
+	// Tag is the discriminator value in Elm source format.
+	Tag string
+}
+
+// PayloadType returns the type of the variant payload in Elm source format.
+func (v *ElmVariant) PayloadType() string {
+	return v.Payload.TypeExpr().Arg()
+}
+
+// PayloadDecoder returns the Elm JSON decoder of the variant payload.
+func (v *ElmVariant) PayloadDecoder(prefix string) string {
+	return v.Payload.DecoderExpr(prefix).Arg()
+}
+
+// PayloadEncoder returns the Elm JSON encoder of the variant payload.
+func (v *ElmVariant) PayloadEncoder(prefix string) string {
+	return v.Payload.EncoderExpr(prefix).Arg()
+}
+
+// Name of this custom type.
+func (u *ElmUnion) Name() string {
+	return u.name
+}
+
+// CamelCasedName leads with lowercase.
+func (u *ElmUnion) CamelCasedName() string {
+	return camelCase(u.name)
+}
+
+// Decoder for this custom type.
+func (u *ElmUnion) Decoder(prefix string) string {
+	return u.CamelCasedName() + "Decoder"
+}
+
+// Encoder for this custom type.
+func (u *ElmUnion) Encoder(prefix string) string {
+	return "encode" + u.name
+}
+
+// TypeExpr returns the Elm type expression.
+func (u *ElmUnion) TypeExpr() *ElmExpr {
+	return elmRef(u.name)
+}
+
+// DecoderExpr returns the Elm JSON decoder expression for this type.
+func (u *ElmUnion) DecoderExpr(prefix string) *ElmExpr {
+	return elmRef(u.Decoder(prefix))
+}
+
+// EncoderExpr returns the Elm JSON encoder expression for this type.
+func (u *ElmUnion) EncoderExpr(prefix string) *ElmExpr {
+	return elmRef(u.Encoder(prefix))
+}
+
+// DiscriminatorLiteral returns the discriminator field name in Elm source format.
+func (u *ElmUnion) DiscriminatorLiteral() string {
+	return elmQuote(u.Discriminator)
+}
+
+// Equal tests for equality with another ElmType.
+func (u *ElmUnion) Equal(other ElmType) bool {
+	if o, ok := other.(*ElmUnion); ok {
+		if u.name != o.name || u.Discriminator != o.Discriminator ||
+			len(u.Variants) != len(o.Variants) {
+			return false
+		}
+		for i, v := range u.Variants {
+			ov := o.Variants[i]
+			if v.Constructor != ov.Constructor || v.Tag != ov.Tag ||
+				elmTypeName(v.Payload) != elmTypeName(ov.Payload) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Nullable indicates whether this type can be nil.
+func (u *ElmUnion) Nullable() bool {
+	return true
+}
+
+// unionMembers returns the named struct types declared in the package of iface that implement
+// it, directly or through a pointer, in source order.
+func unionMembers(iface *types.Named) []*types.Named {
+	pkg := iface.Obj().Pkg()
+	if pkg == nil {
+		return nil
+	}
+	it := iface.Underlying().(*types.Interface)
+	var members []*types.Named
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || tn.IsAlias() {
+			continue
+		}
+		named, ok := tn.Type().(*types.Named)
+		if !ok || named.TypeParams().Len() > 0 {
+			continue
+		}
+		if _, ok := named.Underlying().(*types.Struct); !ok {
+			continue
+		}
+		if types.Implements(named, it) || types.Implements(types.NewPointer(named), it) {
+			members = append(members, named)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Obj().Pos() < members[j].Obj().Pos()
+	})
+	return members
+}
+
+// unionOptions returns the union configuration for the interface, which may be keyed by its
+// qualified or plain name.
+func unionOptions(unions Unions, t *types.Named) (Union, bool) {
+	if u, ok := unions[qualifiedTypeString(t)]; ok {
+		return u, true
+	}
+	u, ok := unions[t.Obj().Name()]
+	return u, ok
+}
+
+// errNotUnion reports an interface type without union configuration.
+func errNotUnion(t *types.Named) error {
+	return errors.Errorf("interface type %s is not supported, configure it as a union with "+
+		"-union %s:<discriminator>", qualifiedTypeString(t), t.Obj().Name())
+}
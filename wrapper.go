@@ -0,0 +1,113 @@
+package main
+
+// ElmWrapper represents a named Go basic type, as either an Elm type alias or an opaque custom
+// type with a single constructor.
+type ElmWrapper struct {
+	name   string
+	basic  *ElmBasicType
+	opaque bool
+}
+
+// Name of this type.
+func (w *ElmWrapper) Name() string {
+	return w.name
+}
+
+// CamelCasedName leads with lowercase.
+func (w *ElmWrapper) CamelCasedName() string {
+	return camelCase(w.name)
+}
+
+// Basic returns the wrapped Elm type.
+func (w *ElmWrapper) Basic() *ElmBasicType {
+	return w.basic
+}
+
+// Opaque indicates this is a custom type, rather than a type alias.
+func (w *ElmWrapper) Opaque() bool {
+	return w.opaque
+}
+
+// Decoder for this type.
+func (w *ElmWrapper) Decoder(prefix string) string {
+	if !w.opaque {
+		return w.basic.Decoder(prefix)
+	}
+	return w.CamelCasedName() + "Decoder"
+}
+
+// Encoder for this type.
+func (w *ElmWrapper) Encoder(prefix string) string {
+	if !w.opaque {
+		return w.basic.Encoder(prefix)
+	}
+	return "encode" + w.name
+}
+
+// TypeExpr returns the Elm type expression.
+func (w *ElmWrapper) TypeExpr() *ElmExpr {
+	return elmRef(w.name)
+}
+
+// DecoderExpr returns the Elm JSON decoder expression for this type.
+func (w *ElmWrapper) DecoderExpr(prefix string) *ElmExpr {
+	return elmRef(w.Decoder(prefix))
+}
+
+// EncoderExpr returns the Elm JSON encoder expression for this type.
+func (w *ElmWrapper) EncoderExpr(prefix string) *ElmExpr {
+	return elmRef(w.Encoder(prefix))
+}
+
+// Wrap returns the name of the function wrapping a value.  The constructor of an opaque type is
+// not exposed.
+func (w *ElmWrapper) Wrap() string {
+	return "wrap" + w.name
+}
+
+// Unwrap returns the name of the function extracting the wrapped value.
+func (w *ElmWrapper) Unwrap() string {
+	return "unwrap" + w.name
+}
+
+// Equal tests for equality with another ElmType.
+func (w *ElmWrapper) Equal(other ElmType) bool {
+	if o, ok := other.(*ElmWrapper); ok {
+		return w.name == o.name &&
+			w.basic.Equal(o.basic) &&
+			w.opaque == o.opaque
+	}
+	return false
+}
+
+// Nullable indicates whether this type can be nil.
+func (w *ElmWrapper) Nullable() bool {
+	return false
+}
+
+// KeyParser returns the Elm function converting a JSON object key into this type.
+func (w *ElmWrapper) KeyParser() string {
+	switch {
+	case !w.opaque || w.basic.KeyParser() == "":
+		return w.basic.KeyParser()
+	case w.basic == elmString:
+		return "(" + w.name + " >> Just)"
+	}
+	return "(" + w.basic.KeyParser() + " >> Maybe.map " + w.name + ")"
+}
+
+// KeyFormatter returns the Elm function converting this type into a JSON object key.
+func (w *ElmWrapper) KeyFormatter() string {
+	switch {
+	case !w.opaque || w.basic.KeyFormatter() == "":
+		return w.basic.KeyFormatter()
+	case w.basic == elmString:
+		return w.Unwrap()
+	}
+	return "(" + w.Unwrap() + " >> " + w.basic.KeyFormatter() + ")"
+}
+
+// Comparable indicates whether this type may be used as an Elm Dict key.
+func (w *ElmWrapper) Comparable() bool {
+	return !w.opaque
+}